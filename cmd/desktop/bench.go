@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// BENCH_CYCLES_PER_TICK is how often the timers tick during a benchmark, as
+// at the default 540Hz, so that programs waiting on the delay timer move on.
+const BENCH_CYCLES_PER_TICK = 9
+
+// runBench implements "g8emu bench [flags] <ROM>": it runs the ROM
+// headless as fast as possible and reports cycles per second of every
+// execution mode, or checks them against the interpreter.
+func runBench(args []string) error {
+	flags := flag.NewFlagSet(os.Args[0]+" bench", flag.ExitOnError)
+	cycles := flags.Int("cycles", 20_000_000, "instructions executed per run")
+	runs := flags.Int("runs", 3, "runs per mode, the fastest one is reported")
+	machineName := flags.String("machine", "chip-8", "machine to emulate: chip-8, chip-48, chip-8x or chip-8-hires")
+	isLockstep := flags.Bool("lockstep", false, "compare every execution mode with the interpreter instead of measuring speed")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s bench [flags] <ROM>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	machine, err := core.ParseMachine(*machineName)
+	if err != nil {
+		return err
+	}
+
+	image, _, err := rom.Load(flags.Arg(0), "", rom.PromptPicker(os.Stdin, os.Stderr))
+	if err != nil {
+		return err
+	}
+
+	if *isLockstep {
+		return benchLockstep(image.Data, machine, *cycles)
+	}
+
+	rates := make(map[core.Execution]float64)
+	for _, execution := range core.Executions() {
+		best := 0.0
+		for range max(*runs, 1) {
+			chip8 := core.NewChip8WithOptions(core.Options{Execution: execution, Seed: 1, Machine: machine})
+			if err := chip8.LoadRomBytes(image.Data); err != nil {
+				return err
+			}
+
+			elapsed := benchRun(chip8, *cycles)
+			best = max(best, float64(*cycles)/elapsed.Seconds())
+		}
+		rates[execution] = best
+	}
+
+	for _, execution := range core.Executions() {
+		fmt.Printf("%-12s %14.0f cycles/s %6.2fx\n", execution, rates[execution], rates[execution]/rates[core.ExecutionInterpreter])
+	}
+
+	return nil
+}
+
+// benchLockstep checks every execution mode against the interpreter.
+func benchLockstep(program []byte, machine core.Machine, cycles int) error {
+	isFailed := false
+	for _, execution := range core.Executions() {
+		if execution == core.ExecutionInterpreter {
+			continue
+		}
+
+		if err := core.Lockstep(program, machine, execution, cycles); err != nil {
+			fmt.Printf("%-12s %v\n", execution, err)
+			isFailed = true
+		} else {
+			fmt.Printf("%-12s matches for %d cycles\n", execution, cycles)
+		}
+	}
+
+	if isFailed {
+		return fmt.Errorf("execution modes disagree with the interpreter")
+	}
+	return nil
+}
+
+func benchRun(chip8 *core.Chip8, cycles int) time.Duration {
+	start := time.Now()
+
+	for ran := 0; ran < cycles; ran += BENCH_CYCLES_PER_TICK {
+		chip8.Run(min(BENCH_CYCLES_PER_TICK, cycles-ran))
+
+		if chip8.DelayTimer > 0 {
+			chip8.DelayTimer--
+		}
+		if chip8.SoundTimer > 0 {
+			chip8.SoundTimer--
+		}
+	}
+
+	return time.Since(start)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/cheat"
+	"github.com/mochaeng/G8Emu/internal/config"
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runCheats implements "g8emu cheats <ROM> [command]", managing the codes
+// saved for a ROM without running it.
+func runCheats(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s cheats <ROM> [command]\n\nCommands:\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, cheat.Help)
+		os.Exit(1)
+	}
+
+	image, _, err := rom.Load(args[0], "", rom.PromptPicker(os.Stdin, os.Stderr))
+	if err != nil {
+		return err
+	}
+
+	cheatDir, err := config.CheatDirectory()
+	if err != nil {
+		return err
+	}
+
+	chip8 := core.NewChip8()
+	engine := emulator.NewEngine(chip8, &emulator.MemoryDisplay{}, &emulator.MemoryInput{}, &emulator.MemoryAudio{}, 540)
+	engine.SetCheatDir(cheatDir)
+	if err := engine.LoadRom(image.Name, image.Data); err != nil {
+		return fmt.Errorf("failed to load ROM: %v", err)
+	}
+
+	command := "list"
+	if len(args) > 1 {
+		command = strings.Join(args[1:], " ")
+	}
+
+	output, err := engine.CheatCommand(command)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// runCheatConsole reads cheat commands from stdin while the game runs and
+// prints their output.
+func runCheatConsole(engine *emulator.Engine) {
+	fmt.Println("cheat console ready, type help for commands")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var output string
+		var err error
+		done := make(chan struct{})
+
+		line := scanner.Text()
+		engine.Do(func() {
+			output, err = engine.CheatCommand(line)
+			close(done)
+		})
+		<-done
+
+		if err != nil {
+			fmt.Println("error:", err)
+		} else if output != "" {
+			fmt.Println(output)
+		}
+	}
+}
@@ -1,47 +1,341 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/mochaeng/G8Emu/internal/constants"
+	"github.com/mochaeng/G8Emu/internal/cheat"
+	"github.com/mochaeng/G8Emu/internal/config"
 	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/ebitenui"
 	"github.com/mochaeng/G8Emu/internal/emulator"
+	"github.com/mochaeng/G8Emu/internal/library"
+	"github.com/mochaeng/G8Emu/internal/rom"
+	"github.com/mochaeng/G8Emu/internal/romdb"
+	"github.com/mochaeng/G8Emu/internal/tui"
 )
 
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [<Scale> [<ROM>]]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Scale: Integer scale factor (default 10)\n")
+	fmt.Fprintf(os.Stderr, "   ROM: Path to ROM file; without it a launcher lists the ROM directories\n")
+	fmt.Fprintf(os.Stderr, "\n       %s tui [flags] <ROM>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Runs the ROM inside the terminal\n")
+	fmt.Fprintf(os.Stderr, "\n       %s cheats <ROM> [command]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Manages the cheat codes saved for the ROM\n")
+	fmt.Fprintf(os.Stderr, "\n       %s patch <ROM> <patch> <output>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Writes the ROM with an IPS or BPS patch applied\n")
+	fmt.Fprintf(os.Stderr, "\n       %s bench [flags] <ROM>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Measures the speed of every execution mode\n")
+	fmt.Fprintf(os.Stderr, "\n       %s recompile [-o <file>] <ROM>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "   Translates the ROM into the Go source of a standalone program\n")
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <Scale> <Delay> <ROM>\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "   Scale: Integer scale factor (e.g., 10)\n")
-		fmt.Fprintf(os.Stderr, "   ROM: Path to ROM file\n")
+	if len(os.Args) > 1 && os.Args[1] == "tui" {
+		if err := tui.Run(os.Args[0]+" tui", os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "patch" {
+		if err := runPatch(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "cheats" {
+		if err := runCheats(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := runBench(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "recompile" {
+		if err := runRecompile(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("using default config: %v", err)
+	}
+
+	paletteSpec := flag.String("palette", cfg.Palette, "colour palette name or 2-4 comma separated hex colours")
+	pixelStyleName := flag.String("pixel", cfg.PixelStyle, "pixel style: square, grid or led")
+	filterName := flag.String("filter", cfg.Filter, "display filter: none, decay, blend or wait")
+	fadeTime := flag.String("fade", cfg.FadeTime, "fade out time of the decay filter")
+	blendFrames := flag.Int("blend", cfg.BlendFrames, "number of frames averaged by the blend filter")
+	shaderSpec := flag.String("shader", cfg.Shader, "CRT shader: off, on or parameters like curvature=0.3,scanlines=0.35,bloom=0.4,vignette=0.5,aberration=1")
+	scaleModeName := flag.String("scale-mode", cfg.ScaleMode, "window scaling: integer or fit")
+	fullscreen := flag.Bool("fullscreen", false, "start in fullscreen")
+	recordPath := flag.String("record", "", "record gameplay from startup to a .gif or .y4m file")
+	recordFormat := flag.String("record-format", "gif", "format of recordings started with F9: gif or y4m")
+	recordSkip := flag.Int("frameskip", 0, "frames dropped between recorded frames")
+	recordAudio := flag.Bool("record-audio", false, "also record the buzzer to a .wav file next to the recording")
+	recordPaletteSpec := flag.String("record-palette", "", "palette used for recordings (default: the active palette)")
+	cpuFrequency := flag.Int("frequency", 540, "instructions executed per second (default: the ROM's tickrate if it has one)")
+	quirksSpec := flag.String("quirks", "", "comma separated behaviours of other interpreters: key-on-press, wrap")
+	timingName := flag.String("timing", "fixed", "instruction timing: fixed, at -frequency, or vip, as on the COSMAC VIP")
+	executionName := flag.String("execution", "cached", "how instructions run: cached, interpreter or recompiler")
+	machineName := flag.String("machine", "chip-8", "machine to emulate: chip-8, chip-48, chip-8x or chip-8-hires")
+	patchPath := flag.String("patch", "", "IPS or BPS patch applied to the ROM (default: a .ips or .bps file next to it)")
+	var romDirs stringList
+	flag.Var(&romDirs, "rom-dir", "directory listed by the launcher, in addition to the configured ones (repeatable)")
+	var cheatCodes stringList
+	flag.Var(&cheatCodes, "cheat", "cheat code for this session, e.g. \"freeze 2F0=05\" (repeatable)")
+	watch := flag.Bool("watch", false, "reload the ROM whenever the file changes")
+	watchKeepBreakpoints := flag.Bool("watch-keep-breakpoints", false, "keep breakpoints when the watched ROM reloads")
+	watchState := flag.String("watch-state", "", "save state restored after each reload of the watched ROM")
+	cheatConsole := flag.Bool("cheat-console", false, "read cheat commands such as memory searches from stdin while playing")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() > 2 {
+		usage()
 		os.Exit(1)
 	}
 
-	videoScale, err := strconv.Atoi(os.Args[1])
+	videoScale := 10
+	if flag.NArg() > 0 {
+		if videoScale, err = strconv.Atoi(flag.Arg(0)); err != nil {
+			log.Fatalf("invalid scale factor: %v", err)
+		}
+	}
+
+	palette, err := emulator.ParsePalette(*paletteSpec)
+	if err != nil {
+		log.Fatalf("invalid palette: %v", err)
+	}
+
+	pixelStyle, err := emulator.ParsePixelStyle(*pixelStyleName)
+	if err != nil {
+		log.Fatalf("invalid pixel style: %v", err)
+	}
+
+	filter, err := emulator.ParseDisplayFilter(*filterName)
+	if err != nil {
+		log.Fatalf("invalid display filter: %v", err)
+	}
+
+	fade, err := time.ParseDuration(*fadeTime)
+	if err != nil {
+		log.Fatalf("invalid fade time: %v", err)
+	}
+
+	shaderOptions, err := emulator.ParseShaderOptions(*shaderSpec)
+	if err != nil {
+		log.Fatalf("invalid shader options: %v", err)
+	}
+
+	scaleMode, err := emulator.ParseScaleMode(*scaleModeName)
+	if err != nil {
+		log.Fatalf("invalid scale mode: %v", err)
+	}
+
+	execution, err := core.ParseExecution(*executionName)
+	if err != nil {
+		log.Fatalf("invalid execution: %v", err)
+	}
+
+	timing, err := emulator.ParseTiming(*timingName)
+	if err != nil {
+		log.Fatalf("invalid timing: %v", err)
+	}
+
+	quirks, err := core.ParseQuirks(*quirksSpec)
+	if err != nil {
+		log.Fatalf("invalid quirks: %v", err)
+	}
+
+	machine, err := core.ParseMachine(*machineName)
 	if err != nil {
-		log.Fatalf("invalid scale factor: %v", err)
+		log.Fatalf("invalid machine: %v", err)
 	}
 
-	romFilename := os.Args[2]
+	if err := emulator.CheckCpuFrequency(*cpuFrequency); err != nil {
+		log.Fatalf("invalid frequency: %v", err)
+	}
 
-	platform := emulator.NewPlatform(videoScale)
-	chip8 := core.NewChip8()
+	settings := emulator.DisplaySettings{
+		Palette:    palette,
+		PixelStyle: pixelStyle,
+		ScaleMode:  scaleMode,
+		Filter: emulator.FilterOptions{
+			Filter:      filter,
+			FadeTime:    fade,
+			BlendFrames: *blendFrames,
+		},
+		Shader:     shaderOptions,
+		Fullscreen: *fullscreen,
+	}
 
-	if err := chip8.LoadRomFile(romFilename); err != nil {
-		log.Fatalf("failed to load ROM: %v", err)
+	historyPath, err := config.HistoryPath()
+	if err != nil {
+		log.Printf("play history will not be saved: %v", err)
+	}
+	history, err := library.LoadHistory(historyPath)
+	if err != nil {
+		log.Printf("play history reset: %v", err)
 	}
 
-	ebiten.SetWindowSize(constants.VIDEO_WIDTH*videoScale, constants.VIDEO_HEIGHT*videoScale)
+	chip8 := core.NewChip8WithOptions(core.Options{Execution: execution, Quirks: quirks, Machine: machine})
+	platform := ebitenui.NewPlatform(videoScale)
+
+	width, height := chip8.VideoSize()
+	ebiten.SetWindowSize(width*videoScale, height*videoScale)
 	ebiten.SetWindowTitle("G8Emu")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetFullscreen(*fullscreen)
+
+	var audio emulator.Audio
+	if audio, err = ebitenui.NewAudio(); err != nil {
+		log.Printf("sound disabled: %v", err)
+		audio = &emulator.MemoryAudio{}
+	}
+
+	engine := emulator.NewEngine(chip8, platform, platform, audio, *cpuFrequency)
+	engine.SetDisplaySettings(settings)
+	engine.SetCaptureScale(videoScale)
+	engine.SetTiming(timing)
+
+	if cheatDir, err := config.CheatDirectory(); err != nil {
+		log.Printf("cheats will not be saved: %v", err)
+	} else {
+		engine.SetCheatDir(cheatDir)
+	}
+
+	if stateDir, err := config.StateDirectory(); err != nil {
+		log.Printf("save states will be written to the current directory: %v", err)
+	} else {
+		engine.SetStateDir(stateDir)
+	}
+
+	s := &session{
+		engine:         engine,
+		cfg:            cfg,
+		history:        history,
+		paletteSpec:    *paletteSpec,
+		isPaletteSet:   isFlagSet("palette"),
+		cpuFrequency:   *cpuFrequency,
+		isFrequencySet: isFlagSet("frequency"),
+	}
+
+	for _, text := range cheatCodes {
+		code, err := cheat.ParseCode(text)
+		if err != nil {
+			log.Fatalf("invalid cheat: %v", err)
+		}
+		s.cheatCodes = append(s.cheatCodes, code)
+	}
+
+	if *watch {
+		s.watch = &emulator.WatchOptions{
+			KeepBreakpoints: *watchKeepBreakpoints,
+			StatePath:       *watchState,
+		}
+	}
+
+	if s.droppedDir, err = config.DroppedDirectory(); err != nil {
+		log.Printf("dropped ROMs will not be listed as recent: %v", err)
+	}
 
-	cpuFrequency := 540
-	game := emulator.NewGame(platform, chip8, cpuFrequency)
+	runner := ebitenui.NewGame(engine, platform)
+	runner.SetRomLoader(s)
 
-	if err := ebiten.RunGame(game); err != nil {
-		log.Fatal(err)
+	var game ebiten.Game = runner
+	if flag.NArg() == 2 {
+		if err := s.load(flag.Arg(1), *patchPath, rom.PromptPicker(os.Stdin, os.Stderr)); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		game = newLauncher(s, cfg, append(cfg.RomDirs, romDirs...), game)
 	}
 
+	if *cheatConsole {
+		go runCheatConsole(engine)
+	}
+
+	if screenshotDir, err := cfg.ScreenshotDirectory(); err != nil {
+		log.Printf("screenshots will be saved to the current directory: %v", err)
+	} else {
+		engine.SetScreenshotDir(screenshotDir)
+	}
+
+	recordOptions := emulator.RecorderOptions{
+		Path:      "." + *recordFormat,
+		Scale:     videoScale,
+		FrameSkip: *recordSkip,
+		Audio:     *recordAudio,
+	}
+	if *recordPaletteSpec != "" {
+		if recordOptions.Palette, err = emulator.ParsePalette(*recordPaletteSpec); err != nil {
+			log.Fatalf("invalid recording palette: %v", err)
+		}
+	}
+	engine.SetRecordDefaults(recordOptions)
+
+	if *recordPath != "" {
+		recordOptions.Path = *recordPath
+		if err := engine.StartRecording(recordOptions); err != nil {
+			log.Fatalf("failed to start recording: %v", err)
+		}
+	}
+
+	runErr := ebiten.RunGame(game)
+
+	if err := engine.StopRecording(); err != nil {
+		log.Printf("failed to save recording: %v", err)
+	}
+
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+}
+
+// newLauncher scans dirs and returns the launcher, which starts game once a
+// ROM is picked.
+func newLauncher(s *session, cfg *config.Config, dirs []string, game ebiten.Game) *ebitenui.Launcher {
+	db := &romdb.Database{}
+	if dbDir, err := cfg.RomDatabaseDirectory(); err == nil {
+		if db, err = romdb.Load(dbDir); err != nil {
+			log.Printf("ROM titles unavailable: %v", err)
+		}
+	}
+
+	screenshotDir, _ := cfg.ScreenshotDirectory()
+	entries, err := library.Scan(dirs, db, s.history, screenshotDir)
+	if err != nil {
+		log.Print(err)
+	}
+
+	return ebitenui.NewLauncher(entries, dirs, s, game)
+}
+
+func isFlagSet(name string) bool {
+	isSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+	return isSet
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// runPatch implements "g8emu patch <ROM> <patch> <output>".
+func runPatch(args []string) error {
+	if len(args) != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s patch <ROM> <patch> <output>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	image, _, err := rom.Load(args[0], args[1], rom.PromptPicker(os.Stdin, os.Stderr))
+	if err != nil {
+		return err
+	}
+	data := image.Data
+
+	if err := os.WriteFile(args[2], data, 0o644); err != nil {
+		return fmt.Errorf("failed to write patched ROM: %v", err)
+	}
+
+	fmt.Printf("wrote %s (%d bytes)\n", args[2], len(data))
+	return nil
+}
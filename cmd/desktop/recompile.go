@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/rom"
+	"github.com/mochaeng/G8Emu/internal/translate"
+)
+
+// runRecompile implements "g8emu recompile [-o <file>] [-machine <name>]
+// <ROM>", writing the ROM as the Go source of a standalone program.
+func runRecompile(args []string) error {
+	flags := flag.NewFlagSet(os.Args[0]+" recompile", flag.ExitOnError)
+	outputPath := flags.String("o", "", "output file (default: standard output)")
+	machineName := flags.String("machine", "chip-8", "machine the ROM is for: chip-8, chip-48, chip-8x or chip-8-hires")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s recompile [-o <file>] [-machine <name>] <ROM>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	machine, err := core.ParseMachine(*machineName)
+	if err != nil {
+		return err
+	}
+
+	image, _, err := rom.Load(flags.Arg(0), "", rom.PromptPicker(os.Stdin, os.Stderr))
+	if err != nil {
+		return err
+	}
+
+	source, analysis, err := translate.Translate(filepath.Base(image.Name), image.Data, machine)
+	if err != nil {
+		return err
+	}
+
+	if *outputPath == "" {
+		_, err = os.Stdout.Write(source)
+	} else {
+		err = os.WriteFile(*outputPath, source, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write Go source: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "translated %d instructions\n", len(analysis.Code))
+	if len(analysis.Indirect) > 0 {
+		addrs := make([]string, len(analysis.Indirect))
+		for i, addr := range analysis.Indirect {
+			addrs[i] = fmt.Sprintf("%03X", addr)
+		}
+		fmt.Fprintf(os.Stderr, "indirect jumps at %s continue on the interpreter until they reach translated code\n", strings.Join(addrs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/mochaeng/G8Emu/internal/cheat"
+	"github.com/mochaeng/G8Emu/internal/config"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+	"github.com/mochaeng/G8Emu/internal/library"
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// session loads ROMs into the running engine, applying the palette and
+// speed chosen for each, and records them in the play history.
+type session struct {
+	engine  *emulator.Engine
+	cfg     *config.Config
+	history *library.History
+
+	// paletteSpec and cpuFrequency come from the flags, which take
+	// precedence over per-ROM settings when set explicitly.
+	paletteSpec    string
+	isPaletteSet   bool
+	cpuFrequency   int
+	isFrequencySet bool
+
+	// cheatCodes from -cheat only apply to the first ROM loaded.
+	cheatCodes []cheat.Code
+
+	// watch, when set, reloads every ROM loaded once its file changes. Its
+	// save state only applies to the first ROM.
+	watch *emulator.WatchOptions
+
+	// droppedDir receives copies of ROMs dropped on the window.
+	droppedDir string
+}
+
+// LoadFile switches to another ROM without restarting.
+func (s *session) LoadFile(path string) error {
+	if err := s.load(path, "", nil); err != nil {
+		return err
+	}
+	ebiten.SetWindowTitle("G8Emu - " + filepath.Base(path))
+	return nil
+}
+
+// LoadDropped copies a dropped ROM next to the config file, so that the
+// recent list can open it again, and loads the copy. When it cannot be
+// kept, the ROM is loaded from memory.
+func (s *session) LoadDropped(name string, data []byte) error {
+	if s.droppedDir != "" {
+		path, err := library.KeepDropped(s.droppedDir, name, data)
+		if err == nil {
+			return s.LoadFile(path)
+		}
+		log.Printf("dropped ROM not kept: %v", err)
+	}
+
+	image, err := rom.Decode(filepath.Base(name), data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if err := s.play(image, filepath.Base(name)); err != nil {
+		return err
+	}
+	ebiten.SetWindowTitle("G8Emu - " + filepath.Base(name))
+	return nil
+}
+
+// Recent returns the most recently played ROMs still on disk.
+func (s *session) Recent(n int) []string {
+	var paths []string
+	for _, path := range s.history.Recent(len(s.history.Played)) {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+		if len(paths) == n {
+			break
+		}
+	}
+	return paths
+}
+
+func (s *session) load(romPath, patchPath string, pick rom.Picker) error {
+	image, appliedPatch, err := rom.Load(romPath, patchPath, pick)
+	if err != nil {
+		return err
+	}
+	if appliedPatch != "" {
+		log.Printf("applied patch %s", appliedPatch)
+	}
+
+	if err := s.play(image, filepath.Base(romPath)); err != nil {
+		return err
+	}
+
+	if s.watch != nil {
+		options := *s.watch
+		options.PatchPath = patchPath
+		if err := s.engine.Watch(romPath, options); err != nil {
+			log.Print(err)
+		}
+		s.watch.StatePath = ""
+	}
+
+	if err := s.history.Touch(romPath, time.Now()); err != nil {
+		log.Printf("failed to update history: %v", err)
+	}
+
+	return nil
+}
+
+// play starts image with the palette and speed chosen for it, fileName
+// being the name it is known by in the config.
+func (s *session) play(image *rom.Image, fileName string) error {
+	cpuFrequency := s.cpuFrequency
+	if image.TickRate > 0 && !s.isFrequencySet {
+		cpuFrequency = image.TickRate * 60
+	}
+
+	paletteSpec := s.paletteSpec
+	if romPalette, ok := s.cfg.RomPalettes[fileName]; ok && !s.isPaletteSet {
+		paletteSpec = romPalette
+	} else if len(image.Colors) > 0 && !s.isPaletteSet {
+		paletteSpec = strings.Join(image.Colors, ",")
+	}
+
+	palette, err := emulator.ParsePalette(paletteSpec)
+	if err != nil {
+		return fmt.Errorf("invalid palette: %v", err)
+	}
+
+	if err := s.engine.LoadRom(image.Name, image.Data); err != nil {
+		return fmt.Errorf("failed to load ROM: %v", err)
+	}
+
+	s.engine.SetCpuFrequency(cpuFrequency)
+	settings := s.engine.DisplaySettings()
+	settings.Palette = palette
+	s.engine.SetDisplaySettings(settings)
+
+	for _, code := range s.cheatCodes {
+		s.engine.Cheats().Add(code, "")
+	}
+	s.cheatCodes = nil
+
+	return nil
+}
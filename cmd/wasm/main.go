@@ -1,11 +1,16 @@
 package main
 
 import (
+	"errors"
+	"log"
+	"strings"
 	"syscall/js"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/ebitenui"
 	"github.com/mochaeng/G8Emu/internal/emulator"
+	"github.com/mochaeng/G8Emu/internal/rom"
 )
 
 func main() {
@@ -13,9 +18,22 @@ func main() {
 	const frequency = 540
 
 	chip8 := core.NewChip8()
-	platform := emulator.NewPlatform(scale)
-	engine := emulator.NewGame(platform, chip8, frequency)
+	platform := ebitenui.NewPlatform(scale)
 
+	var audio emulator.Audio
+	audio, err := ebitenui.NewAudio()
+	if err != nil {
+		log.Printf("sound disabled: %v", err)
+		platform.Notify("sound disabled")
+		audio = &emulator.MemoryAudio{}
+	}
+
+	engine := emulator.NewEngine(chip8, platform, platform, audio, frequency)
+	engine.SetCaptureScale(scale)
+
+	// loadRom unpacks archives and cartridges and applies their settings.
+	// An archive holding several ROMs loads the entry named by the third
+	// argument; without it the names are returned for the page to ask.
 	loadRom := func(this js.Value, args []js.Value) any {
 		if len(args) == 0 || args[0].IsNull() {
 			return js.ValueOf("No ROM data provided")
@@ -25,21 +43,64 @@ func main() {
 		romData := make([]byte, dataLength)
 		js.CopyBytesToGo(romData, args[0])
 
-		engine.Reset()
+		name := "rom.ch8"
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			name = args[1].String()
+		}
+
+		var choices []any
+		pick := func(names []string) (int, error) {
+			if len(args) > 2 && args[2].Type() == js.TypeString {
+				for i, name := range names {
+					if name == args[2].String() {
+						return i, nil
+					}
+				}
+			}
+			for _, name := range names {
+				choices = append(choices, name)
+			}
+			return 0, errors.New("no ROM chosen")
+		}
 
-		if err := chip8.LoadRomBytes(romData); err != nil {
+		image, err := rom.Decode(name, romData, pick)
+		if choices != nil {
+			return js.ValueOf(choices)
+		}
+		if err == nil {
+			err = engine.LoadRom(image.Name, image.Data)
+		}
+		if err != nil {
 			js.Global().Call("alert", "ROM load error: "+err.Error())
 			return js.ValueOf(err.Error())
 		}
 
+		if image.TickRate > 0 {
+			engine.SetCpuFrequency(image.TickRate * 60)
+		}
+		if len(image.Colors) > 0 {
+			if palette, err := emulator.ParsePalette(strings.Join(image.Colors, ",")); err == nil {
+				settings := engine.DisplaySettings()
+				settings.Palette = palette
+				engine.SetDisplaySettings(settings)
+			}
+		}
+
 		return nil
 	}
 
+	// resetEmulator is a soft reset, keeping memory outside the fontset
+	// and the program; powerCycle clears all of it.
 	resetEmulator := func(this js.Value, args []js.Value) any {
 		engine.Reset()
 		return nil
 	}
 
+	powerCycle := func(this js.Value, args []js.Value) any {
+		engine.PowerCycle()
+		return nil
+	}
+
 	togglePause := func(this js.Value, args []js.Value) any {
 		if engine.IsPaused() {
 			engine.Resume()
@@ -50,19 +111,211 @@ func main() {
 	}
 
 	setCpuFrequency := func(this js.Value, args []js.Value) any {
-		// freq := args[0].Int()
-		// game.setCpuFrequency(freq)
+		if len(args) == 0 {
+			return js.ValueOf("No frequency provided")
+		}
+
+		engine.SetCpuFrequency(args[0].Int())
+		return nil
+	}
+
+	setPalette := func(this js.Value, args []js.Value) any {
+		if len(args) == 0 {
+			return js.ValueOf("No palette provided")
+		}
+
+		palette, err := emulator.ParsePalette(args[0].String())
+		if err != nil {
+			return js.ValueOf(err.Error())
+		}
+
+		settings := engine.DisplaySettings()
+		settings.Palette = palette
+		engine.SetDisplaySettings(settings)
+		return nil
+	}
+
+	setPixelStyle := func(this js.Value, args []js.Value) any {
+		if len(args) == 0 {
+			return js.ValueOf("No pixel style provided")
+		}
+
+		style, err := emulator.ParsePixelStyle(args[0].String())
+		if err != nil {
+			return js.ValueOf(err.Error())
+		}
+
+		settings := engine.DisplaySettings()
+		settings.PixelStyle = style
+		engine.SetDisplaySettings(settings)
+		return nil
+	}
+
+	// The CRT shader is off by default on the web, where low-end devices
+	// may not keep up with it.
+	setShader := func(this js.Value, args []js.Value) any {
+		if len(args) == 0 {
+			return js.ValueOf("No shader options provided")
+		}
+
+		options, err := emulator.ParseShaderOptions(args[0].String())
+		if err != nil {
+			return js.ValueOf(err.Error())
+		}
+
+		settings := engine.DisplaySettings()
+		settings.Shader = options
+		engine.SetDisplaySettings(settings)
+		return nil
+	}
+
+	takeScreenshot := func(this js.Value, args []js.Value) any {
+		scale := 1
+		if len(args) > 0 && args[0].Type() == js.TypeNumber {
+			scale = args[0].Int()
+		}
+
+		data, err := engine.Screenshot(scale)
+		if err != nil {
+			return js.ValueOf(err.Error())
+		}
+
+		png := js.Global().Get("Uint8Array").New(len(data))
+		js.CopyBytesToJS(png, data)
+		return png
+	}
+
+	// readMemory returns the bytes from start on with flags for recent
+	// program reads (1) and writes (2) of each. At most the whole memory
+	// is returned.
+	readMemory := func(this js.Value, args []js.Value) any {
+		if len(args) < 2 {
+			return js.ValueOf("Expected start address and length")
+		}
+
+		start, length := uint16(args[0].Int()), args[1].Int()
+		data := engine.PeekMemory(start, length)
+		access := engine.MemoryAccess(start, length)
+		length = len(data)
+
+		bytes := js.Global().Get("Uint8Array").New(length)
+		js.CopyBytesToJS(bytes, data)
+
+		flags := make([]byte, length)
+		for i, a := range access {
+			flags[i] = byte(a)
+		}
+		accessArray := js.Global().Get("Uint8Array").New(length)
+		js.CopyBytesToJS(accessArray, flags)
+
+		return js.ValueOf(map[string]any{"bytes": bytes, "access": accessArray})
+	}
+
+	writeMemory := func(this js.Value, args []js.Value) any {
+		if len(args) < 2 {
+			return js.ValueOf("Expected address and value")
+		}
+
+		engine.PokeMemory(uint16(args[0].Int()), uint8(args[1].Int()))
+		return nil
+	}
+
+	getRegisters := func(this js.Value, args []js.Value) any {
+		state := engine.State()
+
+		v := make([]any, len(state.V))
+		for i, value := range state.V {
+			v[i] = int(value)
+		}
+		stack := make([]any, len(state.Stack))
+		for i, addr := range state.Stack {
+			stack[i] = int(addr)
+		}
+
+		return js.ValueOf(map[string]any{
+			"v":     v,
+			"i":     int(state.I),
+			"pc":    int(state.PC),
+			"sp":    int(state.SP),
+			"dt":    int(state.DT),
+			"st":    int(state.ST),
+			"stack": stack,
+
+			"waitingForKey": state.IsWaitingKey,
+		})
+	}
+
+	setRegister := func(this js.Value, args []js.Value) any {
+		if len(args) < 2 {
+			return js.ValueOf("Expected register name and value")
+		}
+
+		if err := engine.SetRegister(args[0].String(), args[1].Int()); err != nil {
+			return js.ValueOf(err.Error())
+		}
 		return nil
 	}
 
+	// cheatCommand runs a cheat command and returns its output, or the
+	// error message prefixed with "error: ".
+	cheatCommand := func(this js.Value, args []js.Value) any {
+		if len(args) == 0 {
+			return js.ValueOf("error: no command provided")
+		}
+
+		output, err := engine.CheatCommand(args[0].String())
+		if err != nil {
+			return js.ValueOf("error: " + err.Error())
+		}
+		return js.ValueOf(output)
+	}
+
+	// getCheats and setCheats move the cheat codes of the loaded ROM in and
+	// out of browser storage, keyed by romHash.
+	getCheats := func(this js.Value, args []js.Value) any {
+		data, err := engine.Cheats().Encode()
+		if err != nil {
+			return js.ValueOf("")
+		}
+		return js.ValueOf(string(data))
+	}
+
+	setCheats := func(this js.Value, args []js.Value) any {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return js.ValueOf("No cheats provided")
+		}
+
+		if err := engine.Cheats().Decode([]byte(args[0].String())); err != nil {
+			return js.ValueOf(err.Error())
+		}
+		return nil
+	}
+
+	romHash := func(this js.Value, args []js.Value) any {
+		return js.ValueOf(engine.RomHash())
+	}
+
 	js.Global().Set("loadRom", js.FuncOf(loadRom))
 	js.Global().Set("resetEmulator", js.FuncOf(resetEmulator))
+	js.Global().Set("powerCycle", js.FuncOf(powerCycle))
 	js.Global().Set("togglePause", js.FuncOf(togglePause))
 	js.Global().Set("setCpuFrequency", js.FuncOf(setCpuFrequency))
+	js.Global().Set("setPalette", js.FuncOf(setPalette))
+	js.Global().Set("setPixelStyle", js.FuncOf(setPixelStyle))
+	js.Global().Set("setShader", js.FuncOf(setShader))
+	js.Global().Set("takeScreenshot", js.FuncOf(takeScreenshot))
+	js.Global().Set("readMemory", js.FuncOf(readMemory))
+	js.Global().Set("writeMemory", js.FuncOf(writeMemory))
+	js.Global().Set("getRegisters", js.FuncOf(getRegisters))
+	js.Global().Set("setRegister", js.FuncOf(setRegister))
+	js.Global().Set("cheatCommand", js.FuncOf(cheatCommand))
+	js.Global().Set("getCheats", js.FuncOf(getCheats))
+	js.Global().Set("setCheats", js.FuncOf(setCheats))
+	js.Global().Set("romHash", js.FuncOf(romHash))
 
 	go func() {
-		if err := ebiten.RunGame(engine); err != nil {
-			println("Game error: ", err)
+		if err := ebiten.RunGame(ebitenui.NewGame(engine, platform)); err != nil {
+			log.Printf("game error: %v", err)
 		}
 	}()
 
@@ -0,0 +1,139 @@
+// Package cheat finds values in CHIP-8 memory and keeps them locked with
+// cheat codes, which are saved per ROM.
+package cheat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Entry is a cheat code in a ROM's list.
+type Entry struct {
+	Code    Code
+	Name    string
+	Enabled bool
+
+	isArmed bool
+}
+
+type entryFile struct {
+	Code    string `json:"code"`
+	Name    string `json:"name,omitempty"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Cheats is the list of cheat codes of a ROM together with the state of an
+// ongoing memory search.
+type Cheats struct {
+	Entries []*Entry
+
+	search *Search
+	path   string
+}
+
+// Load reads the cheats saved for a ROM in dir, keyed by its SHA-1. A ROM
+// without saved cheats gets an empty list. An empty dir keeps the list in
+// memory only.
+func Load(dir, romHash string) (*Cheats, error) {
+	c := &Cheats{}
+	if dir == "" || romHash == "" {
+		return c, nil
+	}
+	c.path = filepath.Join(dir, romHash+".json")
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read cheats: %v", err)
+	}
+
+	if err := c.Decode(data); err != nil {
+		return c, fmt.Errorf("failed to parse cheats %s: %v", c.path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the list next to the other saved cheats. Lists not loaded
+// from a directory are not saved.
+func (c *Cheats) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := c.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cheats directory: %v", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cheats: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Cheats) Encode() ([]byte, error) {
+	entries := make([]entryFile, len(c.Entries))
+	for i, entry := range c.Entries {
+		entries[i] = entryFile{Code: entry.Code.String(), Name: entry.Name, Enabled: entry.Enabled}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cheats: %v", err)
+	}
+	return data, nil
+}
+
+// Decode replaces the list with codes encoded by Encode.
+func (c *Cheats) Decode(data []byte) error {
+	var entries []entryFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	c.Entries = c.Entries[:0]
+	for _, entry := range entries {
+		code, err := ParseCode(entry.Code)
+		if err != nil {
+			return err
+		}
+		c.Add(code, entry.Name).Enabled = entry.Enabled
+	}
+
+	return nil
+}
+
+// Add appends an enabled code to the list.
+func (c *Cheats) Add(code Code, name string) *Entry {
+	entry := &Entry{Code: code, Name: name, Enabled: true, isArmed: true}
+	c.Entries = append(c.Entries, entry)
+	return entry
+}
+
+// Apply writes the enabled codes to memory. It is called once per frame.
+func (c *Cheats) Apply(memory Memory) {
+	for _, entry := range c.Entries {
+		if entry.Enabled {
+			entry.Code.apply(memory, entry.isArmed)
+			entry.isArmed = false
+		}
+	}
+}
+
+// Rearm makes "set" codes apply again, after the program restarted.
+func (c *Cheats) Rearm() {
+	for _, entry := range c.Entries {
+		entry.isArmed = true
+	}
+}
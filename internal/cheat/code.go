@@ -0,0 +1,116 @@
+package cheat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is how a cheat code changes memory.
+type Kind int
+
+const (
+	// KindSet writes the value once, when the code is enabled or the
+	// program restarts.
+	KindSet Kind = iota
+	// KindFreeze writes the value every frame.
+	KindFreeze
+	// KindConditional writes the value every frame in which the condition
+	// address holds the condition value.
+	KindConditional
+)
+
+// Code is a single cheat. Its text form, accepted by ParseCode, is one of
+//
+//	set ADDR=VV
+//	freeze ADDR=VV
+//	if ADDR=VV ADDR=VV
+//
+// with hexadecimal addresses and values. A bare "ADDR=VV" is a freeze.
+type Code struct {
+	Kind      Kind
+	Address   uint16
+	Value     uint8
+	Condition struct {
+		Address uint16
+		Value   uint8
+	}
+}
+
+func ParseCode(text string) (Code, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 1 {
+		fields = []string{"freeze", fields[0]}
+	}
+
+	var code Code
+	var err error
+
+	switch {
+	case len(fields) == 2 && fields[0] == "set":
+		code.Kind = KindSet
+	case len(fields) == 2 && fields[0] == "freeze":
+		code.Kind = KindFreeze
+	case len(fields) == 3 && fields[0] == "if":
+		code.Kind = KindConditional
+		if code.Condition.Address, code.Condition.Value, err = parseAssignment(fields[1]); err != nil {
+			return Code{}, err
+		}
+	default:
+		return Code{}, fmt.Errorf("invalid cheat code %q: expected \"set ADDR=VV\", \"freeze ADDR=VV\" or \"if ADDR=VV ADDR=VV\"", text)
+	}
+
+	if code.Address, code.Value, err = parseAssignment(fields[len(fields)-1]); err != nil {
+		return Code{}, err
+	}
+
+	return code, nil
+}
+
+func parseAssignment(text string) (uint16, uint8, error) {
+	addrText, valueText, ok := strings.Cut(text, "=")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid assignment %q: expected ADDR=VV", text)
+	}
+
+	addr, err := strconv.ParseUint(strings.TrimPrefix(addrText, "0x"), 16, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid address %q: %v", addrText, err)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimPrefix(valueText, "0x"), 16, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid value %q: %v", valueText, err)
+	}
+
+	return uint16(addr), uint8(value), nil
+}
+
+func (c Code) String() string {
+	switch c.Kind {
+	case KindSet:
+		return fmt.Sprintf("set %03X=%02X", c.Address, c.Value)
+	case KindConditional:
+		return fmt.Sprintf("if %03X=%02X %03X=%02X", c.Condition.Address, c.Condition.Value, c.Address, c.Value)
+	default:
+		return fmt.Sprintf("freeze %03X=%02X", c.Address, c.Value)
+	}
+}
+
+// apply writes the code's value if it should be written this frame.
+// isArmed is true for the first frame after the code was enabled or the
+// program restarted.
+func (c Code) apply(memory Memory, isArmed bool) {
+	switch c.Kind {
+	case KindSet:
+		if !isArmed {
+			return
+		}
+	case KindConditional:
+		if memory.Peek(c.Condition.Address) != c.Condition.Value {
+			return
+		}
+	}
+
+	memory.Poke(c.Address, c.Value)
+}
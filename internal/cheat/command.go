@@ -0,0 +1,143 @@
+package cheat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MAX_RESULTS is how many search candidates the results command lists.
+const MAX_RESULTS = 20
+
+// Help lists the commands accepted by Exec.
+const Help = `search                     start a new search over all memory
+search eq VALUE            keep addresses holding VALUE (decimal, or hex with 0x)
+search changed|unchanged   keep addresses that changed or not since the last step
+search inc|dec             keep addresses that increased or decreased
+results                    list the remaining addresses
+add CODE [NAME]            add a code: set ADDR=VV, freeze ADDR=VV or if ADDR=VV ADDR=VV
+list                       list the codes
+enable N, disable N        turn code N on or off
+remove N                   delete code N`
+
+// Exec runs a cheat command and returns its output. Commands changing the
+// list save it.
+func (c *Cheats) Exec(memory Memory, command string) (string, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	switch fields[0] {
+	case "help":
+		return Help, nil
+
+	case "search":
+		return c.execSearch(memory, fields[1:])
+
+	case "results":
+		if c.search == nil {
+			return "", fmt.Errorf("no search in progress")
+		}
+		var out strings.Builder
+		candidates := c.search.Candidates(memory)
+		for i, candidate := range candidates {
+			if i == MAX_RESULTS {
+				fmt.Fprintf(&out, "... %d more\n", len(candidates)-MAX_RESULTS)
+				break
+			}
+			fmt.Fprintf(&out, "%03X = %02X (%d)\n", candidate.Address, candidate.Value, candidate.Value)
+		}
+		return strings.TrimRight(out.String(), "\n"), nil
+
+	case "add":
+		length := 1
+		if len(fields) > 1 {
+			switch fields[1] {
+			case "set", "freeze":
+				length = 2
+			case "if":
+				length = 3
+			}
+		}
+		if len(fields) < 1+length {
+			return "", fmt.Errorf("usage: add CODE [NAME]")
+		}
+
+		code, err := ParseCode(strings.Join(fields[1:1+length], " "))
+		if err != nil {
+			return "", err
+		}
+		c.Add(code, strings.Join(fields[1+length:], " "))
+		return fmt.Sprintf("added %d: %s", len(c.Entries), code), c.Save()
+
+	case "list":
+		if len(c.Entries) == 0 {
+			return "no cheat codes", nil
+		}
+		var out strings.Builder
+		for i, entry := range c.Entries {
+			state := " "
+			if entry.Enabled {
+				state = "x"
+			}
+			line := fmt.Sprintf("%d [%s] %s %s", i+1, state, entry.Code, entry.Name)
+			out.WriteString(strings.TrimSpace(line) + "\n")
+		}
+		return strings.TrimRight(out.String(), "\n"), nil
+
+	case "enable", "disable", "remove":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: %s N", fields[0])
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > len(c.Entries) {
+			return "", fmt.Errorf("no cheat code %s", fields[1])
+		}
+
+		entry := c.Entries[n-1]
+		switch fields[0] {
+		case "enable":
+			entry.Enabled = true
+			entry.isArmed = true
+		case "disable":
+			entry.Enabled = false
+		case "remove":
+			c.Entries = append(c.Entries[:n-1], c.Entries[n:]...)
+		}
+		return fmt.Sprintf("%sd %d: %s", fields[0], n, entry.Code), c.Save()
+	}
+
+	return "", fmt.Errorf("unknown command %q, try help", fields[0])
+}
+
+func (c *Cheats) execSearch(memory Memory, args []string) (string, error) {
+	if len(args) == 0 {
+		c.search = NewSearch(memory)
+		return fmt.Sprintf("new search, %d candidates", memory.MemorySize()), nil
+	}
+
+	if c.search == nil {
+		c.search = NewSearch(memory)
+	}
+
+	comparison, err := ParseComparison(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	var value uint8
+	if comparison == CompareEqual {
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: search eq VALUE")
+		}
+		parsed, err := strconv.ParseUint(args[1], 0, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid value %q: %v", args[1], err)
+		}
+		value = uint8(parsed)
+	}
+
+	count := c.search.Filter(memory, comparison, value)
+	return fmt.Sprintf("%d candidates", count), nil
+}
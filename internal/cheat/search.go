@@ -0,0 +1,125 @@
+package cheat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Memory is the part of the emulated machine the cheat engine works on.
+// core.Chip8 implements it.
+type Memory interface {
+	Peek(addr uint16) uint8
+	Poke(addr uint16, value uint8)
+	MemorySize() int
+}
+
+// Comparison selects which search candidates are kept, comparing the
+// current value of every address with the given value or with its value at
+// the previous search step.
+type Comparison int
+
+const (
+	CompareEqual Comparison = iota
+	CompareChanged
+	CompareUnchanged
+	CompareIncreased
+	CompareDecreased
+)
+
+var comparisonNames = [...]string{
+	CompareEqual:     "eq",
+	CompareChanged:   "changed",
+	CompareUnchanged: "unchanged",
+	CompareIncreased: "inc",
+	CompareDecreased: "dec",
+}
+
+func (c Comparison) String() string {
+	if int(c) < len(comparisonNames) {
+		return comparisonNames[c]
+	}
+	return fmt.Sprintf("Comparison(%d)", int(c))
+}
+
+func ParseComparison(name string) (Comparison, error) {
+	for i, comparisonName := range comparisonNames {
+		if comparisonName == name {
+			return Comparison(i), nil
+		}
+	}
+	return CompareEqual, fmt.Errorf("unknown comparison %q (available: %s)", name, strings.Join(comparisonNames[:], ", "))
+}
+
+// Candidate is an address still matching every search step.
+type Candidate struct {
+	Address uint16
+	Value   uint8
+}
+
+// Search narrows down the addresses holding a value, e.g. the number of
+// lives, by repeatedly filtering them while the game runs.
+type Search struct {
+	candidates []uint16
+	previous   []uint8
+}
+
+// NewSearch starts a search with every address as a candidate.
+func NewSearch(memory Memory) *Search {
+	s := &Search{
+		candidates: make([]uint16, memory.MemorySize()),
+		previous:   make([]uint8, memory.MemorySize()),
+	}
+
+	for i := range s.candidates {
+		s.candidates[i] = uint16(i)
+	}
+	s.snapshot(memory)
+
+	return s
+}
+
+// Filter keeps the candidates matching the comparison and returns how many
+// are left. The value is only used by CompareEqual.
+func (s *Search) Filter(memory Memory, comparison Comparison, value uint8) int {
+	kept := s.candidates[:0]
+	for _, addr := range s.candidates {
+		current, previous := memory.Peek(addr), s.previous[addr]
+
+		var isMatch bool
+		switch comparison {
+		case CompareEqual:
+			isMatch = current == value
+		case CompareChanged:
+			isMatch = current != previous
+		case CompareUnchanged:
+			isMatch = current == previous
+		case CompareIncreased:
+			isMatch = current > previous
+		case CompareDecreased:
+			isMatch = current < previous
+		}
+
+		if isMatch {
+			kept = append(kept, addr)
+		}
+	}
+
+	s.candidates = kept
+	s.snapshot(memory)
+
+	return len(s.candidates)
+}
+
+func (s *Search) Candidates(memory Memory) []Candidate {
+	candidates := make([]Candidate, len(s.candidates))
+	for i, addr := range s.candidates {
+		candidates[i] = Candidate{Address: addr, Value: memory.Peek(addr)}
+	}
+	return candidates
+}
+
+func (s *Search) snapshot(memory Memory) {
+	for i := range s.previous {
+		s.previous[i] = memory.Peek(uint16(i))
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type Config struct {
+	Palette    string `json:"palette"`
+	PixelStyle string `json:"pixelStyle"`
+
+	Filter      string `json:"filter"`
+	FadeTime    string `json:"fadeTime"`
+	BlendFrames int    `json:"blendFrames"`
+
+	Shader    string `json:"shader"`
+	ScaleMode string `json:"scaleMode"`
+
+	// ScreenshotDir defaults to a "screenshots" directory next to the
+	// config file.
+	ScreenshotDir string `json:"screenshotDir"`
+
+	// RomPalettes overrides Palette for specific ROMs, keyed by ROM file
+	// name (e.g. "tetris.ch8").
+	RomPalettes map[string]string `json:"romPalettes"`
+
+	// RomDirs are scanned by the launcher shown when no ROM is given.
+	RomDirs []string `json:"romDirectories"`
+
+	// RomDatabase is the directory holding the ROM database, defaulting to
+	// a "romdb" directory next to the config file.
+	RomDatabase string `json:"romDatabase"`
+}
+
+func Default() *Config {
+	return &Config{
+		Palette:     "classic",
+		PixelStyle:  "square",
+		Filter:      "none",
+		FadeTime:    "100ms",
+		BlendFrames: 3,
+		Shader:      "off",
+		ScaleMode:   "integer",
+		RomPalettes: map[string]string{},
+	}
+}
+
+// Dir returns the directory holding the configuration file and any other
+// per-user emulator data.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user config directory: %v", err)
+	}
+
+	return filepath.Join(dir, "g8emu"), nil
+}
+
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func (c *Config) ScreenshotDirectory() (string, error) {
+	if c.ScreenshotDir != "" {
+		return c.ScreenshotDir, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "screenshots"), nil
+}
+
+// CheatDirectory holds the saved cheat codes, one file per ROM hash.
+func CheatDirectory() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "cheats"), nil
+}
+
+func (c *Config) RomDatabaseDirectory() (string, error) {
+	if c.RomDatabase != "" {
+		return c.RomDatabase, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "romdb"), nil
+}
+
+// DroppedDirectory keeps copies of ROMs dropped on the window, whose
+// original location is unknown, so that they can be opened again.
+func DroppedDirectory() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "dropped"), nil
+}
+
+// StateDirectory holds the save states written with the hotkey.
+func StateDirectory() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "states"), nil
+}
+
+// HistoryPath is the file recording when each ROM was last played.
+func HistoryPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "history.json"), nil
+}
+
+// Load reads the configuration file, falling back to the defaults when it
+// does not exist.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	return nil
+}
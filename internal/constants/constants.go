@@ -3,6 +3,9 @@ package constants
 const (
 	VIDEO_WIDTH  = 64
 	VIDEO_HEIGHT = 32
+	// HIRES_VIDEO_HEIGHT is the height of the two page display of hi-res
+	// CHIP-8.
+	HIRES_VIDEO_HEIGHT = 64
 
 	DEFAULT_FREQUENCY = 600
 	// SCALE_FACTOR = 10
@@ -0,0 +1,76 @@
+package core
+
+// decoded is an instruction fetched and decoded once: its opcode and the
+// handler the dispatch tables resolve it to.
+type decoded struct {
+	opcode  uint16
+	execute func()
+}
+
+// SetExecution switches how instructions are run, dropping everything
+// cached or compiled so far.
+func (c8 *Chip8) SetExecution(execution Execution) {
+	c8.execution = execution
+	c8.invalidateAll()
+}
+
+func (c8 *Chip8) Execution() Execution {
+	return c8.execution
+}
+
+// decode resolves opcode to the handler reached through the tables,
+// without running it.
+func (c8 *Chip8) decode(opcode uint16) func() {
+	if opcode == 0x0000 {
+		return c8.OpNULL
+	}
+
+	switch opcode >> 12 {
+	case 0x0:
+		return c8.table0[opcode&0x000F]
+	case 0x8:
+		return c8.table8[opcode&0x000F]
+	case 0xE:
+		return c8.tableE[opcode&0x000F]
+	case 0xF:
+		if int(opcode&0x00FF) >= len(c8.tableF) {
+			return c8.OpNULL
+		}
+		return c8.tableF[opcode&0x00FF]
+	}
+
+	return c8.table[opcode>>12]
+}
+
+// cycleCached runs the instruction at pc from the cache, decoding it on
+// the first visit.
+func (c8 *Chip8) cycleCached() {
+	instruction := &c8.instructions[c8.pc&0xFFF]
+
+	if instruction.execute == nil {
+		c8.fetch()
+		instruction.opcode = c8.opcode
+		instruction.execute = c8.decode(c8.opcode)
+	} else {
+		c8.opcode = instruction.opcode
+		c8.pc = (c8.pc + 2) & 0xFFF
+	}
+
+	c8.cycles++
+	instruction.execute()
+}
+
+// invalidate drops the cached instructions and compiled blocks that
+// include the byte at addr, which is how self-modifying code is detected.
+func (c8 *Chip8) invalidate(addr uint16) {
+	c8.instructions[addr&0xFFF].execute = nil
+	c8.instructions[(addr-1)&0xFFF].execute = nil
+	c8.blocks.invalidate(addr)
+}
+
+func (c8 *Chip8) invalidateAll() {
+	for i := range len(c8.instructions) {
+		c8.instructions[i].execute = nil
+	}
+	c8.blocks.invalidateAll()
+}
@@ -10,6 +10,10 @@ import (
 
 const (
 	START_ADDRESS = 0x200
+	// CHIP8X_START_ADDRESS is where CHIP-8X programs are loaded, and
+	// HIRES_ENTRY_ADDRESS where hi-res CHIP-8 programs start.
+	CHIP8X_START_ADDRESS = 0x300
+	HIRES_ENTRY_ADDRESS  = 0x2C0
 
 	FONTSET_SIZE          = 80
 	FONTSET_START_ADDRESS = 0x50
@@ -48,11 +52,44 @@ type Chip8 struct {
 	memory    [4096]uint8
 	stack     [16]uint16
 	Keypad    [16]bool
-	Video     [constants.VIDEO_WIDTH * constants.VIDEO_HEIGHT]bool
-
-	rng *rand.Rand
+	// SecondKeypad is the VP-580 keypad read by CHIP-8X.
+	SecondKeypad [16]bool
+	// Video is large enough for every machine; Screen returns the part
+	// that is displayed.
+	Video [constants.VIDEO_WIDTH * constants.HIRES_VIDEO_HEIGHT]bool
+
+	machine Machine
+	// colors is the CHIP-8X foreground colour of every pixel row of every
+	// zone 8 pixels wide, and background the colour behind them.
+	colors     [constants.VIDEO_HEIGHT][constants.VIDEO_WIDTH / 8]uint8
+	background uint8
+
+	rng  *rand.Rand
+	seed int64
+
+	// rom is the image given to LoadRomBytes, copied back by Reset.
+	rom []byte
+
+	quirks Quirks
+	// isWaitingKey is set while FX0A waits, and pressedKey is the key it
+	// waits to be released, -1 until one is pressed.
+	isWaitingKey bool
+	pressedKey   int
 
 	paused bool
+	cycles uint64
+
+	// Cycle count of the latest read and write of every address by an
+	// instruction, zero if it was never accessed.
+	readCycle  [4096]uint64
+	writeCycle [4096]uint64
+
+	// instructions caches the decoded instruction at every address. Writes
+	// to memory invalidate the instructions they overlap.
+	instructions [4096]decoded
+	execution    Execution
+	blocks       blockCache
+	program      Program
 
 	table  [0xF + 1]func()
 	table0 [0xE + 1]func()
@@ -62,21 +99,24 @@ type Chip8 struct {
 }
 
 func NewChip8() *Chip8 {
-	source := rand.NewSource(time.Now().UnixNano())
-	rng := rand.New(source)
+	return NewChip8WithOptions(Options{})
+}
 
+func NewChip8WithOptions(options Options) *Chip8 {
 	chip8 := Chip8{
-		pc:  START_ADDRESS,
-		rng: rng,
+		pc:         options.Machine.spec().entry,
+		machine:    options.Machine,
+		seed:       options.Seed,
+		execution:  options.Execution,
+		quirks:     options.Quirks,
+		pressedKey: -1,
 	}
+	chip8.rng = chip8.newRng()
 
+	chip8.resetColors()
 	chip8.loadFontset()
 	chip8.initTables()
 
-	for i := range len(chip8.memory) {
-		chip8.memory[i] = 0x00
-	}
-
 	return &chip8
 }
 
@@ -84,6 +124,15 @@ func (c8 *Chip8) loadFontset() {
 	for i := range FONTSET_SIZE {
 		c8.memory[FONTSET_START_ADDRESS+i] = fontset[i]
 	}
+	c8.invalidateAll()
+}
+
+func (c8 *Chip8) newRng() *rand.Rand {
+	seed := c8.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
 }
 
 func (c8 *Chip8) randByte() uint8 {
@@ -107,7 +156,26 @@ func (c8 *Chip8) Cycle() {
 		return
 	}
 
+	if c8.program != nil && c8.program.Run(c8, 1) == 1 {
+		return
+	}
+
+	c8.interpret()
+}
+
+// interpret runs the instruction at pc with the selected execution.
+func (c8 *Chip8) interpret() {
+	switch c8.execution {
+	case ExecutionCached:
+		c8.cycleCached()
+		return
+	case ExecutionRecompiler:
+		c8.runBlock(1)
+		return
+	}
+
 	c8.fetch()
+	c8.cycles++
 
 	if c8.opcode == 0x0000 {
 		return
@@ -116,12 +184,48 @@ func (c8 *Chip8) Cycle() {
 	c8.decodeAndExecute()
 }
 
+// Run executes up to n instructions and returns how many ran, fewer when
+// paused. It is faster than calling Cycle n times with the recompiler,
+// which then runs whole blocks.
+func (c8 *Chip8) Run(n int) int {
+	ran := 0
+	for ran < n && !c8.paused {
+		if c8.program != nil {
+			if count := c8.program.Run(c8, n-ran); count > 0 {
+				ran += count
+				continue
+			}
+		}
+
+		if c8.execution == ExecutionRecompiler {
+			ran += c8.runBlock(n - ran)
+		} else {
+			c8.interpret()
+			ran++
+		}
+	}
+	return ran
+}
+
+// tickTimers counts the delay and sound timers down, as every 60Hz.
+func (c8 *Chip8) tickTimers() {
+	if c8.DelayTimer > 0 {
+		c8.DelayTimer--
+	}
+	if c8.SoundTimer > 0 {
+		c8.SoundTimer--
+	}
+}
+
 func (c8 *Chip8) memRead(addr uint16) uint8 {
+	c8.readCycle[addr%4096] = c8.cycles
 	return c8.memory[addr%4096]
 }
 
 func (c8 *Chip8) memWrite(addr uint16, value uint8) {
+	c8.writeCycle[addr%4096] = c8.cycles
 	c8.memory[addr%4096] = value
+	c8.invalidate(addr)
 }
 
 func (c8 *Chip8) DumpMemory(start, end uint16) {
@@ -131,25 +235,33 @@ func (c8 *Chip8) DumpMemory(start, end uint16) {
 	}
 }
 
+// Reset is a soft reset: it clears the CPU state, the display and the
+// timers and copies the fontset and the loaded ROM back into memory. The
+// rest of memory is kept, like on a machine whose reset button is pressed.
 func (c8 *Chip8) Reset() {
-	c8.pc = START_ADDRESS
+	c8.pc = c8.machine.spec().entry
 	c8.sp = 0
 	c8.index = 0
 	c8.DelayTimer = 0
 	c8.SoundTimer = 0
 	c8.opcode = 0
 	c8.paused = false
-	c8.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	c8.cycles = 0
+	c8.isWaitingKey = false
+	c8.pressedKey = -1
+	c8.rng = c8.newRng()
 
 	for i := range len(c8.registers) {
 		c8.registers[i] = 0
 	}
 
 	for i := range len(c8.memory) {
-		c8.memory[i] = 0
+		c8.readCycle[i] = 0
+		c8.writeCycle[i] = 0
 	}
 
-	// c8.loadFontset()
+	c8.loadFontset()
+	copy(c8.memory[c8.machine.spec().loadAddress:], c8.rom)
 
 	for i := range len(c8.stack) {
 		c8.stack[i] = 0
@@ -158,7 +270,17 @@ func (c8 *Chip8) Reset() {
 	for i := range len(c8.Video) {
 		c8.Video[i] = false
 	}
+	c8.resetColors()
+}
 
+// PowerCycle clears all memory before a Reset, as if the machine was
+// switched off and on again.
+func (c8 *Chip8) PowerCycle() {
+	for i := range len(c8.memory) {
+		c8.memory[i] = 0
+	}
+
+	c8.Reset()
 }
 
 func (c8 *Chip8) Pause() {
@@ -173,6 +295,28 @@ func (c8 *Chip8) TogglePause() {
 	c8.paused = !c8.paused
 }
 
+// VideoSize returns the resolution of the display of the machine.
+func (c8 *Chip8) VideoSize() (width, height int) {
+	return constants.VIDEO_WIDTH, c8.machine.spec().videoHeight
+}
+
+// Screen returns the part of the Video buffer that is displayed, row by
+// row.
+func (c8 *Chip8) Screen() []bool {
+	width, height := c8.VideoSize()
+	return c8.Video[:width*height]
+}
+
+// Cycles returns how many instructions were executed since the last reset.
+func (c8 *Chip8) Cycles() uint64 {
+	return c8.cycles
+}
+
+// Opcode returns the most recently fetched instruction.
+func (c8 *Chip8) Opcode() uint16 {
+	return c8.opcode
+}
+
 func (c8 *Chip8) IsPaused() bool {
 	return c8.paused
 }
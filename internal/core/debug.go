@@ -0,0 +1,78 @@
+package core
+
+// PC returns the address of the next instruction.
+func (c8 *Chip8) PC() uint16 {
+	return c8.pc
+}
+
+// SP returns how many return addresses are on the stack.
+func (c8 *Chip8) SP() uint8 {
+	return c8.sp
+}
+
+// Index returns the I register.
+func (c8 *Chip8) Index() uint16 {
+	return c8.index
+}
+
+// Registers returns a copy of V0-VF.
+func (c8 *Chip8) Registers() [16]uint8 {
+	return c8.registers
+}
+
+// CallStack returns the return addresses currently on the stack, oldest
+// first.
+func (c8 *Chip8) CallStack() []uint16 {
+	depth := min(int(c8.sp), len(c8.stack))
+	stack := make([]uint16, depth)
+	copy(stack, c8.stack[:depth])
+	return stack
+}
+
+// Peek reads memory without side effects, for debuggers and tools.
+func (c8 *Chip8) Peek(addr uint16) uint8 {
+	return c8.memory[addr%4096]
+}
+
+// PeekOpcode reads the instruction stored at addr.
+func (c8 *Chip8) PeekOpcode(addr uint16) uint16 {
+	return uint16(c8.Peek(addr))<<8 | uint16(c8.Peek(addr+1))
+}
+
+// Step executes a single instruction, even while paused.
+func (c8 *Chip8) Step() {
+	paused := c8.paused
+	c8.paused = false
+	c8.Cycle()
+	c8.paused = paused
+}
+
+// MemorySize returns the size of the address space.
+func (c8 *Chip8) MemorySize() int {
+	return len(c8.memory)
+}
+
+// Poke writes memory on behalf of a debugger. It is not recorded as an
+// access by the program.
+func (c8 *Chip8) Poke(addr uint16, value uint8) {
+	c8.memory[addr%4096] = value
+	c8.invalidate(addr)
+}
+
+// LastAccess returns the cycle counts of the latest read and write of addr
+// by an instruction, zero when it was never accessed since the last reset.
+func (c8 *Chip8) LastAccess(addr uint16) (read, write uint64) {
+	return c8.readCycle[addr%4096], c8.writeCycle[addr%4096]
+}
+
+func (c8 *Chip8) SetRegister(register int, value uint8) {
+	c8.registers[register&0xF] = value
+}
+
+func (c8 *Chip8) SetIndex(value uint16) {
+	c8.index = value & 0xFFF
+}
+
+func (c8 *Chip8) SetPC(value uint16) {
+	c8.pc = value & 0xFFF
+}
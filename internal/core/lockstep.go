@@ -0,0 +1,88 @@
+package core
+
+import "fmt"
+
+// LOCKSTEP_CYCLES_PER_TICK is how often Lockstep ticks the timers, as at
+// 540Hz.
+const LOCKSTEP_CYCLES_PER_TICK = 9
+
+// Lockstep runs program on machine for the given number of cycles with
+// execution and with the plain interpreter side by side, comparing the machines after
+// every step of execution (a whole block for the recompiler). It returns
+// the first difference found. No keys are pressed, and both machines get
+// the same random numbers.
+func Lockstep(program []byte, machine Machine, execution Execution, cycles int) error {
+	reference := NewChip8WithOptions(Options{Execution: ExecutionInterpreter, Seed: 1, Machine: machine})
+	candidate := NewChip8WithOptions(Options{Execution: execution, Seed: 1, Machine: machine})
+
+	for _, c8 := range []*Chip8{reference, candidate} {
+		if err := c8.LoadRomBytes(program); err != nil {
+			return err
+		}
+	}
+
+	ticks := uint64(0)
+	for candidate.cycles < uint64(cycles) {
+		pc := candidate.pc
+		limit := cycles - int(candidate.cycles)
+
+		var ran int
+		if execution == ExecutionRecompiler {
+			ran = candidate.runBlock(limit)
+		} else {
+			candidate.Cycle()
+			ran = 1
+		}
+		reference.Run(ran)
+
+		if diff := reference.diff(candidate); diff != "" {
+			return fmt.Errorf("%s differs from the interpreter after %d cycles, running from %03X: %s", execution, candidate.cycles, pc, diff)
+		}
+
+		for ; ticks < candidate.cycles/LOCKSTEP_CYCLES_PER_TICK; ticks++ {
+			reference.tickTimers()
+			candidate.tickTimers()
+		}
+	}
+
+	return nil
+}
+
+// diff describes the first difference between the state of the expected
+// machine c8 and other, or returns "" when they match.
+func (c8 *Chip8) diff(other *Chip8) string {
+	switch {
+	case c8.cycles != other.cycles:
+		return fmt.Sprintf("%d cycles, expected %d", other.cycles, c8.cycles)
+	case c8.pc != other.pc:
+		return fmt.Sprintf("PC %03X, expected %03X", other.pc, c8.pc)
+	case c8.opcode != other.opcode:
+		return fmt.Sprintf("opcode %04X, expected %04X", other.opcode, c8.opcode)
+	case c8.index != other.index:
+		return fmt.Sprintf("I %03X, expected %03X", other.index, c8.index)
+	case c8.sp != other.sp || c8.stack != other.stack:
+		return fmt.Sprintf("stack %03X, expected %03X", other.CallStack(), c8.CallStack())
+	case c8.DelayTimer != other.DelayTimer || c8.SoundTimer != other.SoundTimer:
+		return fmt.Sprintf("timers %d/%d, expected %d/%d", other.DelayTimer, other.SoundTimer, c8.DelayTimer, c8.SoundTimer)
+	case c8.isWaitingKey != other.isWaitingKey || c8.pressedKey != other.pressedKey:
+		return fmt.Sprintf("key wait %t/%d, expected %t/%d", other.isWaitingKey, other.pressedKey, c8.isWaitingKey, c8.pressedKey)
+	case c8.Video != other.Video:
+		return "video differs"
+	case c8.colors != other.colors || c8.background != other.background:
+		return "colours differ"
+	}
+
+	for i := range c8.registers {
+		if c8.registers[i] != other.registers[i] {
+			return fmt.Sprintf("V%X %02X, expected %02X", i, other.registers[i], c8.registers[i])
+		}
+	}
+
+	for addr := range c8.memory {
+		if c8.memory[addr] != other.memory[addr] {
+			return fmt.Sprintf("memory at %03X %02X, expected %02X", addr, other.memory[addr], c8.memory[addr])
+		}
+	}
+
+	return ""
+}
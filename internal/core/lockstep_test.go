@@ -0,0 +1,107 @@
+package core
+
+import "testing"
+
+// lockstepPrograms build programs whose code starts at base.
+var lockstepPrograms = []struct {
+	name  string
+	build func(base uint16) []byte
+}{
+	{
+		// FX55 rewrites the operand of the ADD at the start of the block
+		// it ends, which then runs again.
+		name: "FX55 rewriting its block",
+		build: func(base uint16) []byte {
+			operand := base + 3
+			return []byte{
+				0x60, 0x01, // LD V0, 1
+				0x70, 0x01, // ADD V0, 1
+				0xA0 | byte(operand>>8), byte(operand), // LD I, operand
+				0xF0, 0x55, // LD [I], V0
+				0x10 | byte((base+2)>>8), byte(base + 2), // JP the ADD
+			}
+		},
+	},
+	{
+		// FX33 writes its digits over the instructions following it.
+		name: "FX33 rewriting the next instructions",
+		build: func(base uint16) []byte {
+			digits := base + 9
+			return []byte{
+				0x60, 0x00, // LD V0, 0
+				0x70, 0x07, // ADD V0, 7
+				0xA0 | byte(digits>>8), byte(digits), // LD I, digits
+				0xF0, 0x33, // LD B, V0
+				0x71, 0x00, // ADD V1, hundreds
+				0x00, 0x00, // tens and ones
+				0x10 | byte((base+2)>>8), byte(base + 2), // JP the ADD
+			}
+		},
+	},
+	{
+		// FX33 overwrites the ADD and LD I of the block it ends.
+		name: "FX33 rewriting its block",
+		build: func(base uint16) []byte {
+			operand := base + 3
+			return []byte{
+				0x60, 0x05, // LD V0, 5
+				0x70, 0x01, // ADD V0, 1
+				0xA0 | byte(operand>>8), byte(operand), // LD I, operand
+				0xF0, 0x33, // LD B, V0
+				0x10 | byte((base+2)>>8), byte(base + 2), // JP the ADD
+			}
+		},
+	},
+	{
+		// BNNN jumps into a table of ADDs chosen by CXNN.
+		name: "BNNN into a table chosen by CXNN",
+		build: func(base uint16) []byte {
+			table := base + 0x10
+			program := []byte{
+				0xC0, 0x0E, // RND V0, 0E
+				0xB0 | byte(table>>8), byte(table), // JP V0, table
+			}
+			program = append(program, make([]byte, 0x10-len(program))...)
+			for range 8 {
+				program = append(program, 0x71, 0x01) // ADD V1, 1
+			}
+			return append(program, 0x10|byte(base>>8), byte(base)) // JP base
+		},
+	},
+	{
+		// A subroutine draws a random digit, with skips over the call.
+		name: "drawing in a subroutine",
+		build: func(base uint16) []byte {
+			draw := base + 0x0C
+			return []byte{
+				0xC2, 0x0F, // RND V2, 0F
+				0x32, 0x0F, // SE V2, 0F
+				0x20 | byte(draw>>8), byte(draw), // CALL draw
+				0x73, 0x03, // ADD V3, 3
+				0xF3, 0x15, // LD DT, V3
+				0x10 | byte(base>>8), byte(base), // JP base
+				0xF2, 0x29, // LD F, V2
+				0xD3, 0x35, // DRW V3, V3, 5
+				0x00, 0xEE, // RET
+			}
+		},
+	},
+}
+
+func TestLockstep(t *testing.T) {
+	for _, machine := range Machines() {
+		spec := machine.spec()
+		for _, program := range lockstepPrograms {
+			rom := make([]byte, spec.entry-spec.loadAddress)
+			rom = append(rom, program.build(spec.entry)...)
+
+			for _, execution := range []Execution{ExecutionCached, ExecutionRecompiler} {
+				t.Run(machine.String()+"/"+program.name+"/"+execution.String(), func(t *testing.T) {
+					if err := Lockstep(rom, machine, execution, 5000); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+		}
+	}
+}
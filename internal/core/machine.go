@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/constants"
+)
+
+// Machine selects which CHIP-8 variant a Chip8 emulates. Variants differ
+// in their instruction tables, where programs are loaded and the display.
+type Machine int
+
+const (
+	// MachineChip8 is the interpreter of the COSMAC VIP.
+	MachineChip8 Machine = iota
+	// MachineChip48 is the HP 48 interpreter: BXNN jumps to XNN + Vx and
+	// FX55/FX65 leave I incremented by X.
+	MachineChip48
+	// MachineChip8X is the VIP interpreter for the VP-590 colour board and
+	// the VP-580 second keypad. Programs are loaded at 0x300.
+	MachineChip8X
+	// MachineHires is the two page CHIP-8 for the VIP with a 64x64
+	// display. Programs start at 0x2C0, past the patch of the interpreter
+	// they are shipped with.
+	MachineHires
+)
+
+var machineNames = [...]string{
+	MachineChip8:  "chip-8",
+	MachineChip48: "chip-48",
+	MachineChip8X: "chip-8x",
+	MachineHires:  "chip-8-hires",
+}
+
+// machineSpec is the memory layout and display of a machine.
+type machineSpec struct {
+	// loadAddress is where the ROM is copied and entry where execution
+	// starts.
+	loadAddress uint16
+	entry       uint16
+	videoHeight int
+}
+
+var machineSpecs = [...]machineSpec{
+	MachineChip8:  {START_ADDRESS, START_ADDRESS, constants.VIDEO_HEIGHT},
+	MachineChip48: {START_ADDRESS, START_ADDRESS, constants.VIDEO_HEIGHT},
+	MachineChip8X: {CHIP8X_START_ADDRESS, CHIP8X_START_ADDRESS, constants.VIDEO_HEIGHT},
+	MachineHires:  {START_ADDRESS, HIRES_ENTRY_ADDRESS, constants.HIRES_VIDEO_HEIGHT},
+}
+
+func (m Machine) String() string {
+	if int(m) < len(machineNames) {
+		return machineNames[m]
+	}
+	return fmt.Sprintf("Machine(%d)", int(m))
+}
+
+func ParseMachine(name string) (Machine, error) {
+	for i, machineName := range machineNames {
+		if machineName == name {
+			return Machine(i), nil
+		}
+	}
+	return MachineChip8, fmt.Errorf("unknown machine %q (available: %s)", name, strings.Join(machineNames[:], ", "))
+}
+
+// Machines lists every machine.
+func Machines() []Machine {
+	machines := make([]Machine, len(machineNames))
+	for i := range machines {
+		machines[i] = Machine(i)
+	}
+	return machines
+}
+
+// LoadAddress is where the machine copies programs into memory.
+func (m Machine) LoadAddress() uint16 {
+	return m.spec().loadAddress
+}
+
+// Entry is the address the machine starts executing programs at.
+func (m Machine) Entry() uint16 {
+	return m.spec().entry
+}
+
+func (m Machine) spec() machineSpec {
+	if int(m) < len(machineSpecs) {
+		return machineSpecs[m]
+	}
+	return machineSpecs[MachineChip8]
+}
+
+func (c8 *Chip8) Machine() Machine {
+	return c8.machine
+}
+
+// HasColor reports whether the machine colours its display, in which case
+// PixelColor and BackgroundColor give the colours of the pixels.
+func (c8 *Chip8) HasColor() bool {
+	return c8.machine == MachineChip8X
+}
+
+// The CHIP-8X foreground colours, numbered like on the VP-590.
+const (
+	COLOR_BLACK = iota
+	COLOR_RED
+	COLOR_BLUE
+	COLOR_VIOLET
+	COLOR_GREEN
+	COLOR_YELLOW
+	COLOR_AQUA
+	COLOR_WHITE
+)
+
+// The CHIP-8X background colours, in the order 02A0 cycles through them.
+const (
+	BACKGROUND_BLUE = iota
+	BACKGROUND_BLACK
+	BACKGROUND_GREEN
+	BACKGROUND_RED
+)
+
+const (
+	// CHIP8X_DEFAULT_COLOR is the foreground of every zone after a reset.
+	CHIP8X_DEFAULT_COLOR = COLOR_RED
+	// CHIP8X_ZONE_HEIGHT is the height in pixels of the zones coloured by
+	// BXY0.
+	CHIP8X_ZONE_HEIGHT = 4
+)
+
+// PixelColor returns the CHIP-8X foreground colour of the pixel at (x, y),
+// one of the COLOR_ constants. The VP-590 colours zones 8 pixels wide.
+func (c8 *Chip8) PixelColor(x, y int) uint8 {
+	return c8.colors[y%constants.VIDEO_HEIGHT][(x/8)%len(c8.colors[0])]
+}
+
+// BackgroundColor returns the CHIP-8X background colour, one of the
+// BACKGROUND_ constants.
+func (c8 *Chip8) BackgroundColor() uint8 {
+	return c8.background
+}
+
+func (c8 *Chip8) resetColors() {
+	for y := range c8.colors {
+		for x := range c8.colors[y] {
+			c8.colors[y][x] = CHIP8X_DEFAULT_COLOR
+		}
+	}
+	c8.background = BACKGROUND_BLUE
+}
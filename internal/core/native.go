@@ -0,0 +1,43 @@
+package core
+
+// Program is a ROM translated into Go by the recompile command. Run runs
+// the translated instruction at pc and the ones following it, at most
+// budget in all, and returns how many ran. It stops early, possibly
+// without running anything, at code it did not translate or that was
+// overwritten since, which the interpreter runs instead.
+type Program interface {
+	Run(c8 *Chip8, budget int) int
+}
+
+// SetProgram runs translated code wherever it covers the program counter.
+func (c8 *Chip8) SetProgram(program Program) {
+	c8.program = program
+}
+
+// Native gives translated programs direct access to the machine state.
+type Native struct {
+	PC     *uint16
+	Index  *uint16
+	Opcode *uint16
+	Cycles *uint64
+	V      *[16]uint8
+	Memory *[4096]uint8
+}
+
+func (c8 *Chip8) Native() Native {
+	return Native{
+		PC:     &c8.pc,
+		Index:  &c8.index,
+		Opcode: &c8.opcode,
+		Cycles: &c8.cycles,
+		V:      &c8.registers,
+		Memory: &c8.memory,
+	}
+}
+
+// Exec runs opcode as the instruction just fetched, with pc already past
+// it, for the instructions translated programs leave to the interpreter.
+func (c8 *Chip8) Exec(opcode uint16) {
+	c8.opcode = opcode
+	c8.decode(opcode)()
+}
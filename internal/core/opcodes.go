@@ -1,7 +1,5 @@
 package core
 
-import "github.com/mochaeng/G8Emu/internal/constants"
-
 // NULL operation for invalid opcodes
 func (c8 *Chip8) OpNULL() {}
 
@@ -266,45 +264,50 @@ func (c8 *Chip8) OpCXNN() {
 // Display n-byte sprite starting at memory location I at (Vx, Vy)
 //
 // [instruction]: DRW Vx, Vy, nibble
+//
+// [details]: The starting position wraps around the screen, while the
+// part of the sprite past the right or bottom edge is clipped, or wraps
+// around with the Wrap quirk. VF is set to 1 when a lit pixel is erased
 func (c8 *Chip8) OpDXYN() {
 	vx := (c8.opcode & 0x0F00) >> 8
 	vy := (c8.opcode & 0x00F0) >> 4
 
-	x := c8.registers[vx]
-	y := c8.registers[vy]
+	width, videoHeight := c8.VideoSize()
+	x := int(c8.registers[vx]) % width
+	y := int(c8.registers[vy]) % videoHeight
 	height := c8.opcode & 0x000F
 	c8.registers[0xF] = 0
 
 	for row := range height {
-		spriteRowData := c8.memory[c8.index+row]
+		spriteRowData := c8.memRead(c8.index + row)
 
-		screenY := y + uint8(row)
-		if screenY >= constants.VIDEO_HEIGHT {
-			break
+		screenY := y + int(row)
+		if screenY >= videoHeight {
+			if !c8.quirks.Wrap {
+				break
+			}
+			screenY %= videoHeight
 		}
 
-		for col := range uint8(8) {
+		for col := range 8 {
 			screenX := x + col
-			if screenX >= constants.VIDEO_WIDTH {
-				break
+			if screenX >= width {
+				if !c8.quirks.Wrap {
+					break
+				}
+				screenX %= width
 			}
 
 			isSpritePixelOn := (spriteRowData & (0x80 >> col)) != 0
-			pixelPosition := uint16(screenY)*constants.VIDEO_WIDTH + uint16(screenX)
-
-			if pixelPosition >= constants.VIDEO_WIDTH*constants.VIDEO_HEIGHT {
+			if !isSpritePixelOn {
 				continue
 			}
 
-			isScreenPixelOn := c8.Video[pixelPosition]
-
-			if isSpritePixelOn && isScreenPixelOn {
+			pixelPosition := screenY*width + screenX
+			if c8.Video[pixelPosition] {
 				c8.registers[0xF] = 1
 			}
-
-			if isSpritePixelOn {
-				c8.Video[pixelPosition] = !isScreenPixelOn
-			}
+			c8.Video[pixelPosition] = !c8.Video[pixelPosition]
 		}
 	}
 }
@@ -344,44 +347,44 @@ func (c8 *Chip8) OpFX07() {
 // Wait for a key press, store the value of the key in Vx
 //
 // [instruction]: LD Vx, K
+//
+// [details]: The key is stored once it is released, as on the COSMAC VIP,
+// so a key held down does not fire again. With the KeyOnPress quirk the
+// lowest key held is stored right away
 func (c8 *Chip8) OpFX0A() {
 	vx := (c8.opcode & 0x0F00) >> 8
 
-	if c8.Keypad[0] {
-		c8.registers[vx] = 0
-	} else if c8.Keypad[1] {
-		c8.registers[vx] = 1
-	} else if c8.Keypad[2] {
-		c8.registers[vx] = 2
-	} else if c8.Keypad[3] {
-		c8.registers[vx] = 3
-	} else if c8.Keypad[4] {
-		c8.registers[vx] = 4
-	} else if c8.Keypad[5] {
-		c8.registers[vx] = 5
-	} else if c8.Keypad[6] {
-		c8.registers[vx] = 6
-	} else if c8.Keypad[7] {
-		c8.registers[vx] = 7
-	} else if c8.Keypad[8] {
-		c8.registers[vx] = 8
-	} else if c8.Keypad[9] {
-		c8.registers[vx] = 9
-	} else if c8.Keypad[10] {
-		c8.registers[vx] = 10
-	} else if c8.Keypad[11] {
-		c8.registers[vx] = 11
-	} else if c8.Keypad[12] {
-		c8.registers[vx] = 12
-	} else if c8.Keypad[13] {
-		c8.registers[vx] = 13
-	} else if c8.Keypad[14] {
-		c8.registers[vx] = 14
-	} else if c8.Keypad[15] {
-		c8.registers[vx] = 15
-	} else {
+	if c8.quirks.KeyOnPress {
+		for key, isPressed := range c8.Keypad {
+			if isPressed {
+				c8.registers[vx] = uint8(key)
+				return
+			}
+		}
 		c8.pc -= 2
+		return
+	}
+
+	if !c8.isWaitingKey {
+		c8.isWaitingKey = true
+		c8.pressedKey = -1
+	}
+
+	if c8.pressedKey < 0 {
+		for key, isPressed := range c8.Keypad {
+			if isPressed {
+				c8.pressedKey = key
+				break
+			}
+		}
+	} else if !c8.Keypad[c8.pressedKey] {
+		c8.registers[vx] = uint8(c8.pressedKey)
+		c8.isWaitingKey = false
+		c8.pressedKey = -1
+		return
 	}
+
+	c8.pc -= 2
 }
 
 // Set delay timer = Vx.
@@ -425,13 +428,13 @@ func (c8 *Chip8) OpFX33() {
 	vx := (c8.opcode & 0x0F00) >> 8
 	value := c8.registers[vx]
 
-	c8.memory[c8.index+2] = value % 10
+	c8.memWrite(c8.index+2, value%10)
 	value /= 10
 
-	c8.memory[c8.index+1] = value % 10
+	c8.memWrite(c8.index+1, value%10)
 	value /= 10
 
-	c8.memory[c8.index] = value % 10
+	c8.memWrite(c8.index, value%10)
 }
 
 // Store registers V0 through Vx in memory starting at location I.
@@ -441,7 +444,7 @@ func (c8 *Chip8) OpFX55() {
 	vx := (c8.opcode & 0x0F00) >> 8
 
 	for i := range vx + 1 {
-		c8.memory[c8.index+i] = c8.registers[i]
+		c8.memWrite(c8.index+i, c8.registers[i])
 	}
 }
 
@@ -452,6 +455,134 @@ func (c8 *Chip8) OpFX65() {
 	vx := (c8.opcode & 0x0F00) >> 8
 
 	for i := range vx + 1 {
-		c8.registers[i] = c8.memory[c8.index+i]
+		c8.registers[i] = c8.memRead(c8.index + i)
+	}
+}
+
+// Jump to location XNN + Vx, on CHIP-48
+//
+// [instruction]: JP Vx, addr
+func (c8 *Chip8) OpBXNN() {
+	vx := (c8.opcode & 0x0F00) >> 8
+	addr := c8.opcode & 0x0FFF
+	c8.pc = uint16(c8.registers[vx]) + addr
+}
+
+// Store registers V0 through Vx in memory starting at location I, on
+// CHIP-48
+//
+// [instruction]: LD [I], Vx
+//
+// [details]: I is left incremented by X, one less than the registers
+// stored
+func (c8 *Chip8) OpFX55Chip48() {
+	c8.OpFX55()
+	c8.index += (c8.opcode & 0x0F00) >> 8
+}
+
+// Read registers V0 through Vx from memory starting at location I, on
+// CHIP-48
+//
+// [instruction]: LD Vx, [I]
+//
+// [details]: I is left incremented by X, one less than the registers read
+func (c8 *Chip8) OpFX65Chip48() {
+	c8.OpFX65()
+	c8.index += (c8.opcode & 0x0F00) >> 8
+}
+
+// Cycle the background colour through blue, black, green and red, on
+// CHIP-8X
+//
+// [instruction]: COL
+//
+// [details]: table0 dispatches on the last nibble, so the other opcodes
+// ending in 0 still clear the screen like 00E0
+func (c8 *Chip8) Op02A0() {
+	if c8.opcode != 0x02A0 {
+		c8.Op00E0()
+		return
+	}
+	c8.background = (c8.background + 1) % 4
+}
+
+// Add Vy to Vx nibble by nibble, keeping 3 bits of each, on CHIP-8X
+//
+// [instruction]: ADD Vx, Vy
+//
+// [details]: Other 5XYN opcodes skip the next instruction if Vx is equal
+// to Vy, like 5XY0
+func (c8 *Chip8) Op5XY1() {
+	if c8.opcode&0x000F != 0x1 {
+		c8.Op5XY0()
+		return
+	}
+
+	vx := (c8.opcode & 0x0F00) >> 8
+	vy := (c8.opcode & 0x00F0) >> 4
+
+	c8.registers[vx] = ((c8.registers[vx] & 0x77) + (c8.registers[vy] & 0x77)) & 0x77
+}
+
+// Set the foreground colour of zones of the screen to Vy, on CHIP-8X
+//
+// [instruction]: COL Vx, Vy, nibble
+//
+// [details]: BXY0 colours zones of 8x4 pixels: the low nibbles of Vx and
+// Vx+1 are the left and top zones, and their high nibbles how many more
+// zones are coloured right and down. BXYN colours N rows of pixels of the
+// zone at x = Vx, starting at y = Vx+1
+func (c8 *Chip8) OpBXYN() {
+	vx := (c8.opcode & 0x0F00) >> 8
+	vy := (c8.opcode & 0x00F0) >> 4
+	rows := int(c8.opcode & 0x000F)
+
+	horizontal := c8.registers[vx]
+	vertical := c8.registers[(vx+1)&0xF]
+	color := c8.registers[vy] & 0x7
+
+	zones := len(c8.colors[0])
+	if rows == 0 {
+		for zoneY := range int(vertical>>4) + 1 {
+			top := (int(vertical&0xF) + zoneY) * CHIP8X_ZONE_HEIGHT
+			for zoneX := range int(horizontal>>4) + 1 {
+				zone := (int(horizontal&0xF) + zoneX) % zones
+				for row := range CHIP8X_ZONE_HEIGHT {
+					c8.colors[(top+row)%len(c8.colors)][zone] = color
+				}
+			}
+		}
+		return
+	}
+
+	zone := int(horizontal/8) % zones
+	for row := range rows {
+		c8.colors[(int(vertical)+row)%len(c8.colors)][zone] = color
+	}
+}
+
+// Skip next instruction if key with the value of Vx is pressed on the
+// second keypad, on CHIP-8X
+//
+// [instruction]: SKP2 Vx
+func (c8 *Chip8) OpEXF2() {
+	vx := (c8.opcode & 0x0F00) >> 8
+	key := c8.registers[vx] & 0xF
+
+	if c8.SecondKeypad[key] {
+		c8.pc += 2
+	}
+}
+
+// Skip next instruction if key with the value of Vx is not pressed on the
+// second keypad, on CHIP-8X
+//
+// [instruction]: SKNP2 Vx
+func (c8 *Chip8) OpEXF5() {
+	vx := (c8.opcode & 0x0F00) >> 8
+	key := c8.registers[vx] & 0xF
+
+	if !c8.SecondKeypad[key] {
+		c8.pc += 2
 	}
 }
@@ -0,0 +1,244 @@
+package core
+
+import "testing"
+
+func TestOpDXYN(t *testing.T) {
+	tests := []struct {
+		name    string
+		machine Machine
+		quirks  Quirks
+		x, y    uint8
+		index   uint16
+		sprite  []uint8
+		// lit is drawn first, to collide with.
+		lit       [][2]int
+		pixels    [][2]int
+		collision uint8
+	}{
+		{
+			name:   "draws at the position",
+			x:      10,
+			y:      5,
+			index:  0x300,
+			sprite: []uint8{0xC0, 0x80},
+			pixels: [][2]int{{10, 5}, {11, 5}, {10, 6}},
+		},
+		{
+			name:   "start wraps around the screen",
+			x:      64 + 3,
+			y:      32 + 1,
+			index:  0x300,
+			sprite: []uint8{0x80},
+			pixels: [][2]int{{3, 1}},
+		},
+		{
+			name:   "body clipped at the right edge",
+			x:      62,
+			y:      0,
+			index:  0x300,
+			sprite: []uint8{0xF0},
+			pixels: [][2]int{{62, 0}, {63, 0}},
+		},
+		{
+			name:   "body wraps at the right edge",
+			quirks: Quirks{Wrap: true},
+			x:      62,
+			y:      0,
+			index:  0x300,
+			sprite: []uint8{0xF0},
+			pixels: [][2]int{{62, 0}, {63, 0}, {0, 0}, {1, 0}},
+		},
+		{
+			name:   "body clipped at the bottom edge",
+			x:      0,
+			y:      31,
+			index:  0x300,
+			sprite: []uint8{0x80, 0x80},
+			pixels: [][2]int{{0, 31}},
+		},
+		{
+			name:   "body wraps at the bottom edge",
+			quirks: Quirks{Wrap: true},
+			x:      0,
+			y:      31,
+			index:  0x300,
+			sprite: []uint8{0x80, 0x80},
+			pixels: [][2]int{{0, 31}, {0, 0}},
+		},
+		{
+			name:   "I reads across the end of memory",
+			x:      0,
+			y:      0,
+			index:  0xFFF,
+			sprite: []uint8{0x80, 0x40},
+			pixels: [][2]int{{0, 0}, {1, 1}},
+		},
+		{
+			name:   "I at 0xFFE clipped in the corner",
+			x:      62,
+			y:      30,
+			index:  0xFFE,
+			sprite: []uint8{0xFF, 0xFF},
+			pixels: [][2]int{{62, 30}, {63, 30}, {62, 31}, {63, 31}},
+		},
+		{
+			name:   "I at 0xFFE wrapped in the corner",
+			quirks: Quirks{Wrap: true},
+			x:      62,
+			y:      30,
+			index:  0xFFE,
+			sprite: []uint8{0xFF, 0xFF},
+			pixels: func() [][2]int {
+				var pixels [][2]int
+				for _, y := range []int{30, 31} {
+					for _, x := range []int{62, 63, 0, 1, 2, 3, 4, 5} {
+						pixels = append(pixels, [2]int{x, y})
+					}
+				}
+				return pixels
+			}(),
+		},
+		{
+			name:      "erasing a lit pixel sets VF",
+			x:         4,
+			y:         4,
+			index:     0x300,
+			sprite:    []uint8{0xC0},
+			lit:       [][2]int{{5, 4}},
+			pixels:    [][2]int{{4, 4}},
+			collision: 1,
+		},
+		{
+			name:    "hi-res display is 64 rows high",
+			machine: MachineHires,
+			x:       0,
+			y:       40,
+			index:   0x300,
+			sprite:  []uint8{0x80},
+			pixels:  [][2]int{{0, 40}},
+		},
+		{
+			name:    "hi-res start wraps at 64 rows",
+			machine: MachineHires,
+			x:       0,
+			y:       64 + 2,
+			index:   0x300,
+			sprite:  []uint8{0x80},
+			pixels:  [][2]int{{0, 2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c8 := NewChip8WithOptions(Options{Machine: test.machine, Quirks: test.quirks, Seed: 1})
+			width, height := c8.VideoSize()
+
+			for i, row := range test.sprite {
+				c8.memory[(test.index+uint16(i))&0xFFF] = row
+			}
+			for _, pixel := range test.lit {
+				c8.Video[pixel[1]*width+pixel[0]] = true
+			}
+
+			c8.registers[0] = test.x
+			c8.registers[1] = test.y
+			c8.index = test.index
+			c8.opcode = 0xD010 | uint16(len(test.sprite))
+			c8.OpDXYN()
+
+			expected := make(map[int]bool)
+			for _, pixel := range test.pixels {
+				expected[pixel[1]*width+pixel[0]] = true
+			}
+			for i, isLit := range c8.Video {
+				if isLit != expected[i] {
+					t.Errorf("pixel (%d, %d) lit %t, expected %t", i%width, i/width, isLit, expected[i])
+				}
+				if isLit && i >= width*height {
+					t.Errorf("pixel (%d, %d) drawn outside the %dx%d display", i%width, i/width, width, height)
+				}
+			}
+
+			if c8.registers[0xF] != test.collision {
+				t.Errorf("VF %d, expected %d", c8.registers[0xF], test.collision)
+			}
+		})
+	}
+}
+
+func TestOpFX0A(t *testing.T) {
+	tests := []struct {
+		name   string
+		quirks Quirks
+		// keys are held at each execution of FX0A, which is run until it
+		// stores a key or runs out of steps.
+		keys     [][]int
+		isStored bool
+		key      uint8
+	}{
+		{
+			name:     "stores the key once released",
+			keys:     [][]int{{}, {5}, {5}, {}},
+			isStored: true,
+			key:      5,
+		},
+		{
+			name:     "stores the first key held",
+			keys:     [][]int{{9, 2}, {2}, {}},
+			isStored: true,
+			key:      2,
+		},
+		{
+			name: "waits while no key is pressed",
+			keys: [][]int{{}, {}, {}},
+		},
+		{
+			name: "waits while the key is held",
+			keys: [][]int{{7}, {7}, {7}},
+		},
+		{
+			name:     "key on press stores the lowest key held",
+			quirks:   Quirks{KeyOnPress: true},
+			keys:     [][]int{{}, {0xB, 4}},
+			isStored: true,
+			key:      4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c8 := NewChip8WithOptions(Options{Quirks: test.quirks, Seed: 1})
+			c8.registers[3] = 0xFF
+			c8.opcode = 0xF30A
+
+			isStored := false
+			for step, keys := range test.keys {
+				c8.Keypad = [16]bool{}
+				for _, key := range keys {
+					c8.Keypad[key] = true
+				}
+
+				c8.pc = 0x202
+				c8.OpFX0A()
+				if c8.pc == 0x202 {
+					isStored = true
+					if step != len(test.keys)-1 {
+						t.Fatalf("stored a key at step %d of %d", step+1, len(test.keys))
+					}
+				} else if c8.pc != 0x200 {
+					t.Fatalf("PC %03X at step %d, expected 200 or 202", c8.pc, step+1)
+				}
+			}
+
+			if isStored != test.isStored {
+				t.Fatalf("stored a key %t, expected %t", isStored, test.isStored)
+			}
+			if isStored && c8.registers[3] != test.key {
+				t.Errorf("V3 %X, expected %X", c8.registers[3], test.key)
+			}
+			if !isStored && c8.registers[3] != 0xFF {
+				t.Errorf("V3 %X while waiting, expected it unchanged", c8.registers[3])
+			}
+		})
+	}
+}
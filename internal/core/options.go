@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Execution selects how a Chip8 runs instructions. All of them have the
+// same semantics; they differ in speed.
+type Execution int
+
+const (
+	// ExecutionCached interprets instructions decoded once and cached per
+	// address.
+	ExecutionCached Execution = iota
+	// ExecutionInterpreter fetches and decodes every instruction again.
+	ExecutionInterpreter
+	// ExecutionRecompiler compiles basic blocks into closures with their
+	// operands decoded.
+	ExecutionRecompiler
+)
+
+var executionNames = [...]string{
+	ExecutionCached:      "cached",
+	ExecutionInterpreter: "interpreter",
+	ExecutionRecompiler:  "recompiler",
+}
+
+func (e Execution) String() string {
+	if int(e) < len(executionNames) {
+		return executionNames[e]
+	}
+	return fmt.Sprintf("Execution(%d)", int(e))
+}
+
+func (e Execution) Next() Execution {
+	return (e + 1) % Execution(len(executionNames))
+}
+
+func ParseExecution(name string) (Execution, error) {
+	for i, executionName := range executionNames {
+		if executionName == name {
+			return Execution(i), nil
+		}
+	}
+	return ExecutionCached, fmt.Errorf("unknown execution %q (available: %s)", name, strings.Join(executionNames[:], ", "))
+}
+
+// Executions lists every execution mode.
+func Executions() []Execution {
+	executions := make([]Execution, len(executionNames))
+	for i := range executions {
+		executions[i] = Execution(i)
+	}
+	return executions
+}
+
+// Options configure a Chip8 created with NewChip8WithOptions. The zero
+// value is what NewChip8 uses.
+type Options struct {
+	Execution Execution
+	// Seed seeds the random numbers of CXNN when not zero, so that runs
+	// can be reproduced.
+	Seed    int64
+	Quirks  Quirks
+	Machine Machine
+}
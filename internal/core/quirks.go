@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quirks select between behaviours that differ among CHIP-8 interpreters.
+// The zero value behaves like the original COSMAC VIP interpreter.
+type Quirks struct {
+	// KeyOnPress makes FX0A store the lowest key held as soon as one is,
+	// instead of waiting for a key to be pressed and released.
+	KeyOnPress bool
+	// Wrap makes sprites drawn past the right or bottom edge of the screen
+	// continue on the opposite side instead of being clipped.
+	Wrap bool
+}
+
+var quirkNames = [...]struct {
+	name  string
+	field func(q *Quirks) *bool
+}{
+	{"key-on-press", func(q *Quirks) *bool { return &q.KeyOnPress }},
+	{"wrap", func(q *Quirks) *bool { return &q.Wrap }},
+}
+
+// ParseQuirks enables the quirks named in a comma separated list. An empty
+// spec enables none.
+func ParseQuirks(spec string) (Quirks, error) {
+	var quirks Quirks
+
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		isFound := false
+		for _, quirk := range quirkNames {
+			if quirk.name == name {
+				*quirk.field(&quirks) = true
+				isFound = true
+			}
+		}
+		if !isFound {
+			return Quirks{}, fmt.Errorf("unknown quirk %q (available: %s)", name, strings.Join(QuirkNames(), ", "))
+		}
+	}
+
+	return quirks, nil
+}
+
+func QuirkNames() []string {
+	names := make([]string, len(quirkNames))
+	for i, quirk := range quirkNames {
+		names[i] = quirk.name
+	}
+	return names
+}
+
+// String lists the enabled quirks like ParseQuirks accepts them.
+func (q Quirks) String() string {
+	var names []string
+	for _, quirk := range quirkNames {
+		if *quirk.field(&q) {
+			names = append(names, quirk.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (c8 *Chip8) SetQuirks(quirks Quirks) {
+	c8.quirks = quirks
+}
+
+func (c8 *Chip8) Quirks() Quirks {
+	return c8.quirks
+}
+
+// IsWaitingForKey reports whether the program is stopped on FX0A until a
+// key is pressed, so that frontends can show it.
+func (c8 *Chip8) IsWaitingForKey() bool {
+	return c8.isWaitingKey
+}
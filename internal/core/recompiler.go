@@ -0,0 +1,185 @@
+package core
+
+// MAX_BLOCK_INSTRUCTIONS bounds the length of a compiled block, and so how
+// far before a written address blocks overlapping it can start.
+const MAX_BLOCK_INSTRUCTIONS = 32
+
+// block is a run of straight-line instructions compiled into closures. Only
+// its last instruction may jump, skip or write memory, so a block never
+// runs code it has just overwritten.
+type block struct {
+	opcodes []uint16
+	steps   []func()
+}
+
+func (b *block) size() uint16 {
+	return uint16(2 * len(b.steps))
+}
+
+// blockCache holds the compiled blocks by start address. Blocks entered
+// at different addresses may overlap.
+type blockCache struct {
+	blocks [4096]*block
+	// isCode marks the bytes compiled into a block, so that writes to data
+	// skip looking for blocks to drop.
+	isCode [4096]bool
+}
+
+// invalidate drops every block that includes the byte at addr.
+func (bc *blockCache) invalidate(addr uint16) {
+	addr &= 0xFFF
+	if !bc.isCode[addr] {
+		return
+	}
+
+	for distance := range uint16(2 * MAX_BLOCK_INSTRUCTIONS) {
+		start := (addr - distance) & 0xFFF
+		if b := bc.blocks[start]; b != nil && distance < b.size() {
+			bc.blocks[start] = nil
+		}
+	}
+}
+
+func (bc *blockCache) invalidateAll() {
+	bc.blocks = [4096]*block{}
+	bc.isCode = [4096]bool{}
+}
+
+// runBlock runs at most limit instructions of the block at pc, compiling
+// it on the first visit, and returns how many ran.
+func (c8 *Chip8) runBlock(limit int) int {
+	if c8.paused {
+		return 0
+	}
+
+	start := c8.pc & 0xFFF
+	b := c8.blocks.blocks[start]
+	if b == nil {
+		b = c8.compileBlock(start)
+	}
+
+	count := min(limit, len(b.steps))
+	for i := range count {
+		c8.opcode = b.opcodes[i]
+		c8.pc = (c8.pc + 2) & 0xFFF
+		c8.cycles++
+		b.steps[i]()
+	}
+
+	return count
+}
+
+func (c8 *Chip8) compileBlock(start uint16) *block {
+	b := &block{}
+
+	addr := start
+	for len(b.steps) < MAX_BLOCK_INSTRUCTIONS {
+		next := (addr + 1) & 0xFFF
+		opcode := uint16(c8.memory[addr])<<8 | uint16(c8.memory[next])
+		c8.blocks.isCode[addr] = true
+		c8.blocks.isCode[next] = true
+
+		b.opcodes = append(b.opcodes, opcode)
+		b.steps = append(b.steps, c8.compile(opcode))
+
+		if endsBlock(opcode) {
+			break
+		}
+		addr = (addr + 2) & 0xFFF
+	}
+
+	c8.blocks.blocks[start] = b
+	return b
+}
+
+// endsBlock reports whether an instruction may change pc other than by
+// moving to the next instruction, or write memory. Like the tables, it
+// only looks at the low nibble of 0NNN, 8XYN and EXNN.
+func endsBlock(opcode uint16) bool {
+	switch opcode >> 12 {
+	case 0x0:
+		return opcode != 0x0000 && opcode&0x000F == 0xE
+	case 0x1, 0x2, 0x3, 0x4, 0x5, 0x9, 0xB, 0xE:
+		return true
+	case 0xF:
+		switch opcode & 0x00FF {
+		case 0x0A, 0x33, 0x55:
+			return true
+		}
+	}
+	return false
+}
+
+// compile turns the most common instructions into closures with their
+// operands decoded. The others use the handler from the tables, which
+// reads the operands from the opcode set by runBlock.
+func (c8 *Chip8) compile(opcode uint16) func() {
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+	nn := uint8(opcode)
+	nnn := opcode & 0x0FFF
+	v := &c8.registers
+
+	switch opcode >> 12 {
+	case 0x1:
+		return func() { c8.pc = nnn }
+	case 0x3:
+		return func() {
+			if v[x] == nn {
+				c8.pc += 2
+			}
+		}
+	case 0x4:
+		return func() {
+			if v[x] != nn {
+				c8.pc += 2
+			}
+		}
+	case 0x5:
+		if c8.machine == MachineChip8X {
+			// 5XY1 adds on CHIP-8X.
+			break
+		}
+		return func() {
+			if v[x] == v[y] {
+				c8.pc += 2
+			}
+		}
+	case 0x6:
+		return func() { v[x] = nn }
+	case 0x7:
+		return func() { v[x] += nn }
+	case 0x8:
+		switch opcode & 0x000F {
+		case 0x0:
+			return func() { v[x] = v[y] }
+		case 0x1:
+			return func() { v[x] |= v[y] }
+		case 0x2:
+			return func() { v[x] &= v[y] }
+		case 0x3:
+			return func() { v[x] ^= v[y] }
+		}
+	case 0x9:
+		return func() {
+			if v[x] != v[y] {
+				c8.pc += 2
+			}
+		}
+	case 0xA:
+		return func() { c8.index = nnn }
+	case 0xF:
+		switch opcode & 0x00FF {
+		case 0x07:
+			return func() { v[x] = c8.DelayTimer }
+		case 0x15:
+			return func() { c8.DelayTimer = v[x] }
+		case 0x18:
+			return func() { c8.SoundTimer = v[x] }
+		case 0x1E:
+			return func() { c8.index += uint16(v[x]) }
+		}
+	}
+
+	return c8.decode(opcode)
+}
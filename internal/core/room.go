@@ -3,23 +3,43 @@ package core
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+
+	"github.com/mochaeng/G8Emu/internal/rom"
 )
 
+// LoadRomFile loads a ROM from disk, unpacking ZIP archives, Octo
+// cartridges and hex listings. Archives holding several ROMs are an error;
+// frontends pick one with rom.Load.
 func (c8 *Chip8) LoadRomFile(filename string) error {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to read ROM file: %v", err)
 	}
 
-	return c8.LoadRomBytes(data)
+	image, err := rom.Decode(filepath.Base(filename), data, nil)
+	if err != nil {
+		return err
+	}
+
+	return c8.LoadRomBytes(image.Data)
 }
 
+// LoadRomBytes copies a program into memory and keeps it for Reset.
 func (c8 *Chip8) LoadRomBytes(data []byte) error {
-	if len(data) > len(c8.memory)-START_ADDRESS {
-		return fmt.Errorf("ROM too large to fit in memory: %d bytes (max %d)", len(data), len(c8.memory)-START_ADDRESS)
+	loadAddress := int(c8.machine.spec().loadAddress)
+	if len(data) > len(c8.memory)-loadAddress {
+		return fmt.Errorf("ROM too large to fit in memory: %d bytes (max %d)", len(data), len(c8.memory)-loadAddress)
 	}
 
-	copy(c8.memory[START_ADDRESS:], data)
+	c8.rom = append(c8.rom[:0], data...)
+	copy(c8.memory[loadAddress:], data)
+	c8.invalidateAll()
 
 	return nil
 }
+
+// Rom returns the image loaded by LoadRomBytes.
+func (c8 *Chip8) Rom() []byte {
+	return c8.rom
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/mochaeng/G8Emu/internal/constants"
+)
+
+// Snapshot is the complete machine state, for save states. Its fields are
+// exported so that it can be encoded.
+type Snapshot struct {
+	PC         uint16
+	SP         uint8
+	Index      uint16
+	DelayTimer uint8
+	SoundTimer uint8
+	Registers  [16]uint8
+	Stack      [16]uint16
+	Memory     [4096]uint8
+	Video      [constants.VIDEO_WIDTH * constants.VIDEO_HEIGHT]bool
+	Cycles     uint64
+	Rom        []byte
+
+	IsWaitingKey bool
+	PressedKey   int
+
+	// HiresVideo holds the rows of the hi-res display past VIDEO_HEIGHT,
+	// apart from Video so that older states still decode.
+	HiresVideo [constants.VIDEO_WIDTH * (constants.HIRES_VIDEO_HEIGHT - constants.VIDEO_HEIGHT)]bool
+	Colors     [constants.VIDEO_HEIGHT][constants.VIDEO_WIDTH / 8]uint8
+	Background uint8
+
+	// Machine and Quirks are the settings the state was saved with.
+	// Restore leaves the settings of the machine restored to alone.
+	Machine Machine
+	Quirks  Quirks
+}
+
+func (c8 *Chip8) Snapshot() Snapshot {
+	s := Snapshot{
+		PC:         c8.pc,
+		SP:         c8.sp,
+		Index:      c8.index,
+		DelayTimer: c8.DelayTimer,
+		SoundTimer: c8.SoundTimer,
+		Registers:  c8.registers,
+		Stack:      c8.stack,
+		Memory:     c8.memory,
+		Cycles:     c8.cycles,
+		Rom:        append([]byte(nil), c8.rom...),
+
+		IsWaitingKey: c8.isWaitingKey,
+		PressedKey:   c8.pressedKey,
+
+		Colors:     c8.colors,
+		Background: c8.background,
+
+		Machine: c8.machine,
+		Quirks:  c8.quirks,
+	}
+	copy(s.Video[:], c8.Video[:])
+	copy(s.HiresVideo[:], c8.Video[len(s.Video):])
+	return s
+}
+
+// Restore puts the machine back into a snapshotted state. The access
+// history of the memory viewer starts over. Snapshots the machine could not
+// have been in are refused, leaving it unchanged.
+func (c8 *Chip8) Restore(s Snapshot) error {
+	if int(s.SP) > len(c8.stack) {
+		return fmt.Errorf("stack pointer %d past the %d entry stack", s.SP, len(c8.stack))
+	}
+	if s.PressedKey < -1 || s.PressedKey >= len(c8.Keypad) {
+		return fmt.Errorf("pressed key %d out of the keypad", s.PressedKey)
+	}
+
+	c8.pc = s.PC
+	c8.sp = s.SP
+	c8.index = s.Index
+	c8.DelayTimer = s.DelayTimer
+	c8.SoundTimer = s.SoundTimer
+	c8.registers = s.Registers
+	c8.stack = s.Stack
+	c8.memory = s.Memory
+	c8.invalidateAll()
+	copy(c8.Video[:], s.Video[:])
+	copy(c8.Video[len(s.Video):], s.HiresVideo[:])
+	c8.cycles = s.Cycles
+	c8.rom = append(c8.rom[:0], s.Rom...)
+	c8.opcode = 0
+	c8.isWaitingKey = s.IsWaitingKey
+	c8.pressedKey = s.PressedKey
+	c8.colors = s.Colors
+	c8.background = s.Background
+
+	c8.readCycle = [4096]uint64{}
+	c8.writeCycle = [4096]uint64{}
+
+	return nil
+}
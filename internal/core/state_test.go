@@ -0,0 +1,40 @@
+package core
+
+import "testing"
+
+func TestRestoreRefusesInvalidSnapshots(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *Snapshot)
+		isError bool
+	}{
+		{name: "unchanged", modify: func(s *Snapshot) {}},
+		{name: "full stack", modify: func(s *Snapshot) { s.SP = 16 }},
+		{name: "stack pointer past the stack", modify: func(s *Snapshot) { s.SP = 17 }, isError: true},
+		{name: "waiting for a key", modify: func(s *Snapshot) { s.IsWaitingKey, s.PressedKey = true, 15 }},
+		{name: "pressed key past the keypad", modify: func(s *Snapshot) { s.IsWaitingKey, s.PressedKey = true, 16 }, isError: true},
+		{name: "negative pressed key", modify: func(s *Snapshot) { s.IsWaitingKey, s.PressedKey = true, -2 }, isError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c8 := NewChip8WithOptions(Options{Seed: 1})
+			s := c8.Snapshot()
+			s.PC = 0x300
+			test.modify(&s)
+
+			err := c8.Restore(s)
+			if test.isError != (err != nil) {
+				t.Fatalf("error %v, expected one: %t", err, test.isError)
+			}
+
+			expected := uint16(0x300)
+			if test.isError {
+				expected = START_ADDRESS
+			}
+			if c8.PC() != expected {
+				t.Errorf("PC %03X after restoring, expected %03X", c8.PC(), expected)
+			}
+		})
+	}
+}
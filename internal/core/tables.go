@@ -53,6 +53,35 @@ func (c8 *Chip8) initTables() {
 	c8.tableF[0x33] = c8.OpFX33
 	c8.tableF[0x55] = c8.OpFX55
 	c8.tableF[0x65] = c8.OpFX65
+
+	// Hi-res CHIP-8 uses the CHIP-8 tables: the 0230 clearing both pages
+	// reaches 00E0, and DXYN draws on the taller display.
+	switch c8.machine {
+	case MachineChip48:
+		c8.initChip48Tables()
+	case MachineChip8X:
+		c8.initChip8XTables()
+	}
+}
+
+// initChip48Tables replaces the instructions the HP 48 interpreter changed.
+func (c8 *Chip8) initChip48Tables() {
+	c8.table[0xB] = c8.OpBXNN
+
+	c8.tableF[0x55] = c8.OpFX55Chip48
+	c8.tableF[0x65] = c8.OpFX65Chip48
+}
+
+// initChip8XTables adds the colour and second keypad instructions of
+// CHIP-8X.
+func (c8 *Chip8) initChip8XTables() {
+	c8.table[0x5] = c8.Op5XY1
+	c8.table[0xB] = c8.OpBXYN
+
+	c8.table0[0x0] = c8.Op02A0
+
+	c8.tableE[0x2] = c8.OpEXF2
+	c8.tableE[0x5] = c8.OpEXF5
 }
 
 func (c8 *Chip8) Table0() {
@@ -68,5 +97,9 @@ func (c8 *Chip8) TableE() {
 }
 
 func (c8 *Chip8) TableF() {
+	if int(c8.opcode&0x00FF) >= len(c8.tableF) {
+		c8.OpNULL()
+		return
+	}
 	c8.tableF[c8.opcode&0x00FF]()
 }
@@ -0,0 +1,86 @@
+package core
+
+// Timing of the original COSMAC VIP interpreter, in machine cycles of its
+// CDP1802 CPU, each 8 clock periods at 1.7609 MHz.
+const (
+	VIP_CYCLES_PER_SECOND = 1760900 / 8
+	VIP_CYCLES_PER_FRAME  = VIP_CYCLES_PER_SECOND / 60
+
+	// VIP_INTERRUPT_CYCLES are taken from every frame by the CDP1861
+	// display, which reads 128 lines of 8 bytes by DMA, and by the
+	// interrupt routine counting the timers down.
+	VIP_INTERRUPT_CYCLES = 128*8 + 72
+)
+
+// VipCycles returns how long an instruction takes on the VIP, including
+// fetching and decoding it. isSkipped tells whether a skip instruction
+// skipped, and x is the horizontal position of a sprite drawn by DXYN,
+// which takes longer when it straddles two bytes of the display.
+//
+// The costs are approximations of the VIP interpreter's routines; DXYN
+// does not include waiting for the display, which is left to the caller.
+func VipCycles(opcode uint16, isSkipped bool, x uint8) int {
+	skip := 0
+	if isSkipped {
+		skip = 4
+	}
+
+	vx := int(opcode&0x0F00) >> 8
+	n := int(opcode & 0x000F)
+
+	switch opcode >> 12 {
+	case 0x0:
+		switch opcode {
+		case 0x00E0:
+			return 24
+		case 0x00EE:
+			return 10
+		}
+		// Machine language routines are not run.
+		return 4
+	case 0x1:
+		return 12
+	case 0x2:
+		return 26
+	case 0x3, 0x4:
+		return 10 + skip
+	case 0x5, 0x9:
+		return 14 + skip
+	case 0x6:
+		return 6
+	case 0x7:
+		return 10
+	case 0x8:
+		return 44
+	case 0xA:
+		return 12
+	case 0xB:
+		return 22
+	case 0xC:
+		return 36
+	case 0xD:
+		// Sprites not aligned to a byte are shifted and drawn over two
+		// bytes per row.
+		if x%8 == 0 {
+			return 68 + n*46
+		}
+		return 68 + n*73
+	case 0xE:
+		return 14 + skip
+	case 0xF:
+		switch opcode & 0x00FF {
+		case 0x07, 0x0A, 0x15, 0x18:
+			return 10
+		case 0x1E:
+			return 18
+		case 0x29:
+			return 20
+		case 0x33:
+			return 204
+		case 0x55, 0x65:
+			return 22 + 14*(vx+1)
+		}
+	}
+
+	return 4
+}
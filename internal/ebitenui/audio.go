@@ -0,0 +1,65 @@
+package ebitenui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+// Audio plays the buzzer as a square wave through ebiten's audio context.
+type Audio struct {
+	player *audio.Player
+}
+
+func NewAudio() (*Audio, error) {
+	context := audio.CurrentContext()
+	if context == nil {
+		context = audio.NewContext(emulator.AUDIO_SAMPLE_RATE)
+	}
+
+	player, err := context.NewPlayer(&squareWave{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio player: %v", err)
+	}
+	player.SetBufferSize(50 * time.Millisecond)
+	player.SetVolume(0.3)
+
+	return &Audio{player: player}, nil
+}
+
+func (a *Audio) SetBuzzer(isOn bool) {
+	if isOn && !a.player.IsPlaying() {
+		a.player.Play()
+	} else if !isOn && a.player.IsPlaying() {
+		a.player.Pause()
+	}
+}
+
+// squareWave is an endless 16-bit stereo square wave at the buzzer
+// frequency.
+type squareWave struct {
+	position int
+}
+
+func (s *squareWave) Read(buf []byte) (int, error) {
+	const halfPeriod = emulator.AUDIO_SAMPLE_RATE / emulator.BUZZER_FREQUENCY / 2
+	const frameSize = 4
+
+	n := len(buf) / frameSize * frameSize
+	for i := 0; i < n; i += frameSize {
+		sample := int16(0x3FFF)
+		if (s.position/halfPeriod)%2 == 1 {
+			sample = -sample
+		}
+		s.position++
+
+		buf[i] = byte(sample)
+		buf[i+1] = byte(sample >> 8)
+		buf[i+2] = byte(sample)
+		buf[i+3] = byte(sample >> 8)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,110 @@
+package ebitenui
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+// Game runs an Engine inside ebiten's game loop.
+type Game struct {
+	engine   *emulator.Engine
+	platform *Platform
+	loader   RomLoader
+	recent   recentMenu
+	// isResumed is whether emulation was running when the recent list
+	// opened, so that it resumes once the list closes.
+	isResumed bool
+}
+
+func NewGame(engine *emulator.Engine, platform *Platform) *Game {
+	return &Game{
+		engine:   engine,
+		platform: platform,
+	}
+}
+
+// SetRomLoader enables loading ROMs dropped on the window and the recent
+// list.
+func (g *Game) SetRomLoader(loader RomLoader) {
+	g.loader = loader
+}
+
+func (g *Game) Update() error {
+	g.platform.pollHotkeys()
+
+	if g.loader != nil {
+		if isDropped, err := loadDroppedFile(g.loader); isDropped {
+			g.closeRecent()
+			g.report(err)
+		}
+
+		if g.recent.isOpen {
+			path, ok := g.recent.update()
+			if !g.recent.isOpen {
+				g.closeRecent()
+			}
+			if ok {
+				g.report(g.loader.LoadFile(path))
+			}
+			return nil
+		}
+
+		if g.platform.Pressed(emulator.HotkeyRecent) {
+			g.isResumed = !g.engine.IsPaused()
+			g.engine.Pause()
+			g.recent.open(g.loader.Recent(RECENT_ITEMS))
+			return nil
+		}
+	}
+
+	return g.engine.Update()
+}
+
+func (g *Game) closeRecent() {
+	g.recent.isOpen = false
+	if g.isResumed {
+		g.engine.Continue()
+		g.isResumed = false
+	}
+}
+
+func (g *Game) report(err error) {
+	if err != nil {
+		log.Printf("failed to load ROM: %v", err)
+		g.platform.Notify(err.Error())
+	}
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	g.engine.Draw()
+	if g.recent.isOpen {
+		g.platform.PresentOverlay(g.recent.overlay())
+	}
+	g.platform.Draw(screen)
+
+	if g.engine.IsWaitingForKey() && !g.recent.isOpen {
+		drawKeyIndicator(screen)
+	}
+}
+
+// drawKeyIndicator marks the bottom right corner while the program waits
+// for a key.
+func drawKeyIndicator(screen *ebiten.Image) {
+	const label = "PRESS A KEY"
+	bounds := screen.Bounds()
+	width := len(label)*OVERLAY_CHAR_WIDTH + 8
+	x := bounds.Dx() - width - 4
+	y := bounds.Dy() - OVERLAY_LINE_HEIGHT - 8
+
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width), float32(OVERLAY_LINE_HEIGHT+4), color.RGBA{0x00, 0x00, 0x00, 0xB0}, false)
+	ebitenutil.DebugPrintAt(screen, label, x+4, y+1)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return g.platform.Layout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,260 @@
+package ebitenui
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/mochaeng/G8Emu/internal/library"
+)
+
+// Frames a key or button has to be held before it repeats, and the frames
+// between repeats.
+const (
+	REPEAT_DELAY    = 20
+	REPEAT_INTERVAL = 4
+)
+
+// Launcher is an ebiten game listing the ROM library. Once a ROM is picked,
+// or dropped on the window, it hands over to the game running it.
+type Launcher struct {
+	entries    []library.Entry
+	dirs       []string
+	loader     RomLoader
+	next       ebiten.Game
+	game       ebiten.Game
+	selected   int
+	top        int
+	rows       int
+	thumbnails map[string]*ebiten.Image
+	message    string
+	now        func() time.Time
+}
+
+// NewLauncher lists entries, found in dirs, loads the one picked with
+// loader and then runs game.
+func NewLauncher(entries []library.Entry, dirs []string, loader RomLoader, game ebiten.Game) *Launcher {
+	return &Launcher{
+		entries:    entries,
+		dirs:       dirs,
+		loader:     loader,
+		next:       game,
+		rows:       1,
+		thumbnails: make(map[string]*ebiten.Image),
+		now:        time.Now,
+	}
+}
+
+func (l *Launcher) Update() error {
+	if l.game != nil {
+		return l.game.Update()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+
+	if isDropped, err := loadDroppedFile(l.loader); isDropped {
+		l.finish(err)
+		return nil
+	}
+
+	move := 0
+	switch {
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyArrowUp)):
+		move = -1
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyArrowDown)):
+		move = 1
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyPageUp)):
+		move = -l.rows
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyPageDown)):
+		move = l.rows
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		move = -len(l.entries)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		move = len(l.entries)
+	}
+	isPicked := inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+		switch {
+		case isRepeating(inpututil.StandardGamepadButtonPressDuration(id, ebiten.StandardGamepadButtonLeftTop)):
+			move = -1
+		case isRepeating(inpututil.StandardGamepadButtonPressDuration(id, ebiten.StandardGamepadButtonLeftBottom)):
+			move = 1
+		case inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopLeft):
+			move = -l.rows
+		case inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopRight):
+			move = l.rows
+		}
+		if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightBottom) ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonCenterRight) {
+			isPicked = true
+		}
+	}
+
+	if len(l.entries) == 0 {
+		return nil
+	}
+
+	l.selected = min(max(l.selected+move, 0), len(l.entries)-1)
+	if l.selected < l.top {
+		l.top = l.selected
+	}
+	if l.selected >= l.top+l.rows {
+		l.top = l.selected - l.rows + 1
+	}
+
+	if isPicked {
+		l.finish(l.loader.LoadFile(l.entries[l.selected].Path))
+	}
+
+	return nil
+}
+
+// finish hands over to the game once a ROM loaded, or shows why it did
+// not.
+func (l *Launcher) finish(err error) {
+	if err != nil {
+		l.message = err.Error()
+		log.Printf("failed to load ROM: %v", err)
+		return
+	}
+	l.game = l.next
+}
+
+// isRepeating reports whether a key held for the given number of frames
+// triggers this frame.
+func isRepeating(duration int) bool {
+	return duration == 1 || (duration >= REPEAT_DELAY && (duration-REPEAT_DELAY)%REPEAT_INTERVAL == 0)
+}
+
+func (l *Launcher) Draw(screen *ebiten.Image) {
+	if l.game != nil {
+		l.game.Draw(screen)
+		return
+	}
+
+	bounds := screen.Bounds()
+	screen.Fill(color.RGBA{0x10, 0x10, 0x18, 0xFF})
+
+	ebitenutil.DebugPrintAt(screen, "G8Emu - choose a ROM (Up/Down, Enter or gamepad A, Esc quits)", 8, 4)
+
+	if len(l.entries) == 0 {
+		lines := []string{
+			"No ROMs (" + strings.Join(library.Extensions, ", ") + ") found in:",
+		}
+		for _, dir := range l.dirs {
+			lines = append(lines, "  "+dir)
+		}
+		lines = append(lines, "", "Add directories with -rom-dir or \"romDirectories\" in config.json.")
+		for i, line := range lines {
+			ebitenutil.DebugPrintAt(screen, line, 8, 28+i*OVERLAY_LINE_HEIGHT)
+		}
+		return
+	}
+
+	// Every entry takes two lines: the title and when it was last played.
+	listWidth := bounds.Dx() * 3 / 5
+	listTop := 28
+	rowHeight := 2 * OVERLAY_LINE_HEIGHT
+	l.rows = max(1, (bounds.Dy()-listTop-OVERLAY_LINE_HEIGHT)/rowHeight)
+	columns := max(1, (listWidth-16)/OVERLAY_CHAR_WIDTH)
+
+	for row := 0; row < l.rows && l.top+row < len(l.entries); row++ {
+		index := l.top + row
+		entry := l.entries[index]
+		y := listTop + row*rowHeight
+
+		if index == l.selected {
+			vector.DrawFilledRect(screen, 4, float32(y), float32(listWidth-8), float32(rowHeight), color.RGBA{0x30, 0x40, 0x70, 0xFF}, false)
+		}
+		ebitenutil.DebugPrintAt(screen, truncate(entry.Title, columns), 8, y)
+		ebitenutil.DebugPrintAt(screen, truncate("  "+library.FormatLastPlayed(entry.LastPlayed, l.now()), columns), 8, y+OVERLAY_LINE_HEIGHT)
+	}
+
+	l.drawDetails(screen, l.entries[l.selected], listWidth, listTop)
+
+	if l.message != "" {
+		ebitenutil.DebugPrintAt(screen, l.message, 8, bounds.Dy()-OVERLAY_LINE_HEIGHT-2)
+	}
+}
+
+// drawDetails shows the thumbnail and information of the selected entry
+// right of the list.
+func (l *Launcher) drawDetails(screen *ebiten.Image, entry library.Entry, left, top int) {
+	width := screen.Bounds().Dx() - left - 8
+	columns := max(1, width/OVERLAY_CHAR_WIDTH)
+	y := top
+
+	if thumbnail := l.thumbnail(entry.Thumbnail); thumbnail != nil {
+		bounds := thumbnail.Bounds()
+		scale := float64(width) / float64(bounds.Dx())
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(float64(left), float64(y))
+		screen.DrawImage(thumbnail, op)
+		y += int(float64(bounds.Dy())*scale) + 8
+	} else {
+		vector.StrokeRect(screen, float32(left), float32(y), float32(width), float32(width/2), 1, color.RGBA{0x50, 0x50, 0x60, 0xFF}, false)
+		ebitenutil.DebugPrintAt(screen, "no screenshot", left+8, y+8)
+		y += width/2 + 8
+	}
+
+	lines := []string{filepath.Base(entry.Path)}
+	if len(entry.Authors) > 0 {
+		lines = append(lines, "by "+strings.Join(entry.Authors, ", "))
+	}
+	if entry.Release != "" {
+		lines = append(lines, fmt.Sprintf("released %s", entry.Release))
+	}
+	for _, line := range lines {
+		ebitenutil.DebugPrintAt(screen, truncate(line, columns), left, y)
+		y += OVERLAY_LINE_HEIGHT
+	}
+}
+
+// thumbnail loads a screenshot once, remembering failures as nil.
+func (l *Launcher) thumbnail(path string) *ebiten.Image {
+	if path == "" {
+		return nil
+	}
+	if image, ok := l.thumbnails[path]; ok {
+		return image
+	}
+
+	image, _, err := ebitenutil.NewImageFromFile(path)
+	if err != nil {
+		log.Printf("failed to load thumbnail: %v", err)
+		image = nil
+	}
+	l.thumbnails[path] = image
+	return image
+}
+
+func truncate(text string, columns int) string {
+	if len(text) <= columns {
+		return text
+	}
+	if columns < 3 {
+		return text[:columns]
+	}
+	return text[:columns-3] + "..."
+}
+
+func (l *Launcher) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if l.game != nil {
+		return l.game.Layout(outsideWidth, outsideHeight)
+	}
+	return outsideWidth, outsideHeight
+}
@@ -0,0 +1,345 @@
+package ebitenui
+
+import (
+	_ "embed"
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/mochaeng/G8Emu/internal/constants"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+//go:embed shaders/crt.kage
+var crtShaderSource []byte
+
+// Platform is the ebiten implementation of the emulator Display and Input.
+type Platform struct {
+	display      *ebiten.Image
+	pixels       []byte
+	pixelMask    *ebiten.Image
+	maskScale    int
+	maskStyle    emulator.PixelStyle
+	keymap       map[ebiten.Key]int
+	secondKeymap map[ebiten.Key]int
+	hotkeys      map[emulator.Hotkey]ebiten.Key
+	held         map[emulator.Hotkey]bool
+	pressed      map[emulator.Hotkey]bool
+	videoScale   int
+	settings     emulator.DisplaySettings
+
+	// shiftHotkeys trigger with Shift held, instead of the plain hotkey on
+	// the same key.
+	shiftHotkeys map[emulator.Hotkey]ebiten.Key
+
+	shader       *ebiten.Shader
+	shaderFailed bool
+	offscreen    *ebiten.Image
+
+	overlay *emulator.Overlay
+	chars   []rune
+}
+
+// Size in pixels of a character of the ebitenutil debug font.
+const (
+	OVERLAY_CHAR_WIDTH  = 6
+	OVERLAY_LINE_HEIGHT = 16
+)
+
+func NewPlatform(videoScale int) *Platform {
+	p := &Platform{
+		display:    ebiten.NewImage(constants.VIDEO_WIDTH, constants.VIDEO_HEIGHT),
+		pixels:     make([]byte, constants.VIDEO_WIDTH*constants.VIDEO_HEIGHT*4),
+		held:       make(map[emulator.Hotkey]bool),
+		pressed:    make(map[emulator.Hotkey]bool),
+		videoScale: videoScale,
+		settings:   emulator.DefaultDisplaySettings(),
+	}
+
+	p.keymap = map[ebiten.Key]int{
+		ebiten.KeyX: 0x0, ebiten.Key1: 0x1, ebiten.Key2: 0x2, ebiten.Key3: 0x3,
+		ebiten.KeyQ: 0x4, ebiten.KeyW: 0x5, ebiten.KeyE: 0x6, ebiten.KeyA: 0x7,
+		ebiten.KeyS: 0x8, ebiten.KeyD: 0x9, ebiten.KeyZ: 0xA, ebiten.KeyC: 0xB,
+		ebiten.Key4: 0xC, ebiten.KeyR: 0xD, ebiten.KeyF: 0xE, ebiten.KeyV: 0xF,
+	}
+
+	// The second keypad of CHIP-8X is on the numeric keypad.
+	p.secondKeymap = map[ebiten.Key]int{
+		ebiten.KeyNumpad0: 0x0, ebiten.KeyNumpad1: 0x1, ebiten.KeyNumpad2: 0x2, ebiten.KeyNumpad3: 0x3,
+		ebiten.KeyNumpad4: 0x4, ebiten.KeyNumpad5: 0x5, ebiten.KeyNumpad6: 0x6, ebiten.KeyNumpad7: 0x7,
+		ebiten.KeyNumpad8: 0x8, ebiten.KeyNumpad9: 0x9, ebiten.KeyNumpadDivide: 0xA, ebiten.KeyNumpadMultiply: 0xB,
+		ebiten.KeyNumpadSubtract: 0xC, ebiten.KeyNumpadAdd: 0xD, ebiten.KeyNumpadEnter: 0xE, ebiten.KeyNumpadDecimal: 0xF,
+	}
+
+	p.hotkeys = map[emulator.Hotkey]ebiten.Key{
+		emulator.HotkeyPause:       ebiten.KeyP,
+		emulator.HotkeyReset:       ebiten.KeyR,
+		emulator.HotkeyPalette:     ebiten.KeyF2,
+		emulator.HotkeyPixelStyle:  ebiten.KeyF3,
+		emulator.HotkeyFilter:      ebiten.KeyF4,
+		emulator.HotkeyShader:      ebiten.KeyF5,
+		emulator.HotkeyShaderParam: ebiten.KeyF6,
+		emulator.HotkeyShaderUp:    ebiten.KeyPageUp,
+		emulator.HotkeyShaderDown:  ebiten.KeyPageDown,
+		emulator.HotkeyScaleMode:   ebiten.KeyF8,
+		emulator.HotkeyRecord:      ebiten.KeyF9,
+		emulator.HotkeyFullscreen:  ebiten.KeyF11,
+		emulator.HotkeyScreenshot:  ebiten.KeyF12,
+		emulator.HotkeyDebugger:    ebiten.KeyF1,
+		emulator.HotkeyStep:        ebiten.KeyF10,
+		emulator.HotkeyContinue:    ebiten.KeySpace,
+		emulator.HotkeyBreakpoint:  ebiten.KeyB,
+		emulator.HotkeyMemory:      ebiten.KeyM,
+		emulator.HotkeyCursorUp:    ebiten.KeyArrowUp,
+		emulator.HotkeyCursorDown:  ebiten.KeyArrowDown,
+		emulator.HotkeyCursorLeft:  ebiten.KeyArrowLeft,
+		emulator.HotkeyCursorRight: ebiten.KeyArrowRight,
+		emulator.HotkeyEditTarget:  ebiten.KeyTab,
+		emulator.HotkeyRecent:      ebiten.KeyF7,
+	}
+
+	p.shiftHotkeys = map[emulator.Hotkey]ebiten.Key{
+		emulator.HotkeyPowerCycle: ebiten.KeyR,
+		emulator.HotkeySaveState:  ebiten.KeyF12,
+		emulator.HotkeyLoadState:  ebiten.KeyF11,
+	}
+
+	return p
+}
+
+// WindowSize is the initial window size: the native resolution multiplied
+// by the scale factor given to NewPlatform.
+func (p *Platform) WindowSize() (int, int) {
+	bounds := p.display.Bounds()
+	return bounds.Dx() * p.videoScale, bounds.Dy() * p.videoScale
+}
+
+func (p *Platform) Keypad(keys []bool) {
+	for key, chipKey := range p.keymap {
+		keys[chipKey] = ebiten.IsKeyPressed(key)
+	}
+}
+
+func (p *Platform) SecondKeypad(keys []bool) {
+	for key, chipKey := range p.secondKeymap {
+		keys[chipKey] = ebiten.IsKeyPressed(key)
+	}
+}
+
+func (p *Platform) Pressed(hotkey emulator.Hotkey) bool {
+	return p.pressed[hotkey]
+}
+
+// pollHotkeys records which hotkeys went down since the previous update,
+// so holding a hotkey only triggers it once.
+func (p *Platform) pollHotkeys() {
+	isShift := ebiten.IsKeyPressed(ebiten.KeyShift)
+
+	shiftKeys := make(map[ebiten.Key]bool, len(p.shiftHotkeys))
+	for hotkey, key := range p.shiftHotkeys {
+		shiftKeys[key] = true
+		p.poll(hotkey, isShift && ebiten.IsKeyPressed(key))
+	}
+
+	for hotkey, key := range p.hotkeys {
+		p.poll(hotkey, ebiten.IsKeyPressed(key) && !(isShift && shiftKeys[key]))
+	}
+
+	p.chars = ebiten.AppendInputChars(p.chars[:0])
+}
+
+func (p *Platform) poll(hotkey emulator.Hotkey, isPressed bool) {
+	p.pressed[hotkey] = isPressed && !p.held[hotkey]
+	p.held[hotkey] = isPressed
+}
+
+func (p *Platform) InputChars() []rune {
+	return p.chars
+}
+
+func (p *Platform) Notify(message string) {
+	ebiten.SetWindowTitle("G8Emu - " + message)
+}
+
+// Present renders a frame into the native resolution display image. The
+// display is rebuilt whenever the resolution changes, e.g. when a program
+// switches between low and high resolution modes.
+func (p *Platform) Present(frame emulator.Frame, settings emulator.DisplaySettings) {
+	if settings.Fullscreen != p.settings.Fullscreen {
+		ebiten.SetFullscreen(settings.Fullscreen)
+	}
+	p.settings = settings
+
+	if bounds := p.display.Bounds(); bounds.Dx() != frame.Width || bounds.Dy() != frame.Height {
+		p.display = ebiten.NewImage(frame.Width, frame.Height)
+		p.pixels = make([]byte, frame.Width*frame.Height*4)
+		p.pixelMask = nil
+	}
+
+	for i := range frame.Pixels {
+		c := frame.Shade(i, settings.Palette)
+		p.pixels[i*4] = c.R
+		p.pixels[i*4+1] = c.G
+		p.pixels[i*4+2] = c.B
+		p.pixels[i*4+3] = c.A
+	}
+
+	p.display.WritePixels(p.pixels)
+}
+
+func (p *Platform) PresentOverlay(overlay *emulator.Overlay) {
+	p.overlay = overlay
+}
+
+func (p *Platform) Draw(screen *ebiten.Image) {
+	defer p.drawOverlay(screen)
+
+	if !p.settings.Shader.Enabled || !p.loadShader() {
+		p.drawDisplay(screen)
+		return
+	}
+
+	bounds := screen.Bounds()
+	if p.offscreen == nil || p.offscreen.Bounds().Size() != bounds.Size() {
+		p.offscreen = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+	}
+	p.offscreen.Clear()
+	p.drawDisplay(p.offscreen)
+
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = p.offscreen
+	op.Uniforms = p.settings.Shader.Uniforms()
+	screen.DrawRectShader(bounds.Dx(), bounds.Dy(), p.shader, op)
+}
+
+// loadShader compiles the CRT shader on first use. Devices that cannot
+// compile it fall back to plain rendering.
+func (p *Platform) loadShader() bool {
+	if p.shader == nil && !p.shaderFailed {
+		shader, err := ebiten.NewShader(crtShaderSource)
+		if err != nil {
+			log.Printf("CRT shader unavailable, using plain rendering: %v", err)
+			p.shaderFailed = true
+			return false
+		}
+		p.shader = shader
+	}
+
+	return p.shader != nil
+}
+
+func (p *Platform) drawDisplay(screen *ebiten.Image) {
+	bounds := p.display.Bounds()
+	screenBounds := screen.Bounds()
+	scale, offsetX, offsetY := emulator.FitDisplay(screenBounds.Dx(), screenBounds.Dy(), bounds.Dx(), bounds.Dy(), p.settings.ScaleMode)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(offsetX, offsetY)
+	screen.DrawImage(p.display, op)
+
+	style := p.settings.PixelStyle
+	if style == emulator.PixelSquare {
+		return
+	}
+
+	// The mask is built for whole pixel cells and stretched to the
+	// exact scale, which only matters in fit mode.
+	maskScale := max(1, int(math.Ceil(scale)))
+	if p.pixelMask == nil || p.maskScale != maskScale || p.maskStyle != style {
+		p.pixelMask = newPixelMask(bounds.Dx(), bounds.Dy(), maskScale, style)
+		p.maskScale = maskScale
+		p.maskStyle = style
+	}
+
+	maskOp := &ebiten.DrawImageOptions{}
+	maskOp.GeoM.Scale(scale/float64(maskScale), scale/float64(maskScale))
+	maskOp.GeoM.Translate(offsetX, offsetY)
+	maskOp.ColorScale.ScaleWithColor(p.settings.Palette.GapColor())
+	maskOp.Filter = ebiten.FilterLinear
+	screen.DrawImage(p.pixelMask, maskOp)
+}
+
+var highlightColors = map[emulator.HighlightKind]color.RGBA{
+	emulator.HighlightRead:   {0x20, 0x60, 0xC0, 0xFF},
+	emulator.HighlightWrite:  {0xC0, 0x30, 0x30, 0xFF},
+	emulator.HighlightIndex:  {0x20, 0x80, 0x40, 0xFF},
+	emulator.HighlightPC:     {0xA0, 0x70, 0x00, 0xFF},
+	emulator.HighlightCursor: {0x90, 0x90, 0x90, 0xFF},
+}
+
+// drawOverlay draws the overlay text over a translucent background, after
+// the shader so that it stays readable.
+func (p *Platform) drawOverlay(screen *ebiten.Image) {
+	if p.overlay == nil || len(p.overlay.Lines) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, line := range p.overlay.Lines {
+		columns = max(columns, len(line))
+	}
+
+	width := float32(columns*OVERLAY_CHAR_WIDTH + 8)
+	height := float32(len(p.overlay.Lines)*OVERLAY_LINE_HEIGHT + 8)
+	vector.DrawFilledRect(screen, 0, 0, width, height, color.RGBA{0x00, 0x00, 0x00, 0xC0}, false)
+
+	for _, highlight := range p.overlay.Highlights {
+		x := float32(4 + highlight.Column*OVERLAY_CHAR_WIDTH)
+		y := float32(4 + highlight.Line*OVERLAY_LINE_HEIGHT)
+		w := float32(highlight.Length * OVERLAY_CHAR_WIDTH)
+		vector.DrawFilledRect(screen, x, y, w, OVERLAY_LINE_HEIGHT, highlightColors[highlight.Kind], false)
+	}
+
+	for i, line := range p.overlay.Lines {
+		ebitenutil.DebugPrintAt(screen, line, 4, 4+i*OVERLAY_LINE_HEIGHT)
+	}
+}
+
+func (p *Platform) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
+
+// newPixelMask builds a white mask covering the parts of every scaled pixel
+// that should show the gap colour: the right and bottom edges for the grid
+// style and everything outside a circle for the LED style.
+func newPixelMask(width, height, scale int, style emulator.PixelStyle) *ebiten.Image {
+	cell := make([]uint8, scale*scale)
+	gap := max(1, scale/8)
+	center := float64(scale) / 2
+	radius := float64(scale) * 0.45
+
+	for dy := range scale {
+		for dx := range scale {
+			var alpha float64
+			switch style {
+			case emulator.PixelGrid:
+				if dx >= scale-gap || dy >= scale-gap {
+					alpha = 1
+				}
+			case emulator.PixelLED:
+				distance := math.Hypot(float64(dx)+0.5-center, float64(dy)+0.5-center)
+				alpha = math.Min(1, math.Max(0, distance-radius+0.5))
+			}
+			cell[dy*scale+dx] = uint8(alpha * 0xFF)
+		}
+	}
+
+	mask := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+	for y := range height * scale {
+		for x := range width * scale {
+			alpha := cell[(y%scale)*scale+x%scale]
+			offset := mask.PixOffset(x, y)
+			mask.Pix[offset] = alpha
+			mask.Pix[offset+1] = alpha
+			mask.Pix[offset+2] = alpha
+			mask.Pix[offset+3] = alpha
+		}
+	}
+
+	return ebiten.NewImageFromImage(mask)
+}
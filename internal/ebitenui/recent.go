@@ -0,0 +1,114 @@
+package ebitenui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+// RECENT_ITEMS is the length of the recent list, one per digit key.
+const RECENT_ITEMS = 9
+
+// RomLoader switches the ROM of the running engine, for files dropped on
+// the window and the recent list.
+type RomLoader interface {
+	LoadFile(path string) error
+	// LoadDropped loads a file dropped on the window, which only comes
+	// with its base name.
+	LoadDropped(name string, data []byte) error
+	// Recent returns paths of recently played ROMs, most recent first.
+	Recent(n int) []string
+}
+
+// loadDroppedFile loads the first file dropped on the window this frame
+// and reports whether there was one.
+func loadDroppedFile(loader RomLoader) (bool, error) {
+	dropped := ebiten.DroppedFiles()
+	if dropped == nil {
+		return false, nil
+	}
+
+	entries, err := fs.ReadDir(dropped, ".")
+	if err != nil {
+		return true, fmt.Errorf("failed to read dropped files: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(dropped, entry.Name())
+		if err != nil {
+			return true, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+		return true, loader.LoadDropped(entry.Name(), data)
+	}
+
+	return true, fmt.Errorf("no file dropped, only directories")
+}
+
+// recentMenu is the overlay listing recent ROMs, opened with F7. The game
+// is paused while it is open.
+type recentMenu struct {
+	paths    []string
+	selected int
+	isOpen   bool
+}
+
+func (m *recentMenu) open(paths []string) {
+	m.paths = paths
+	m.selected = 0
+	m.isOpen = true
+}
+
+// update handles the menu keys and returns the path picked, if any.
+func (m *recentMenu) update() (string, bool) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyF7) {
+		m.isOpen = false
+		return "", false
+	}
+
+	if len(m.paths) == 0 {
+		return "", false
+	}
+
+	switch {
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyArrowUp)):
+		m.selected = max(m.selected-1, 0)
+	case isRepeating(inpututil.KeyPressDuration(ebiten.KeyArrowDown)):
+		m.selected = min(m.selected+1, len(m.paths)-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		m.isOpen = false
+		return m.paths[m.selected], true
+	}
+
+	for i := range m.paths {
+		if inpututil.IsKeyJustPressed(ebiten.KeyDigit1 + ebiten.Key(i)) {
+			m.isOpen = false
+			return m.paths[i], true
+		}
+	}
+
+	return "", false
+}
+
+func (m *recentMenu) overlay() *emulator.Overlay {
+	overlay := &emulator.Overlay{Lines: []string{"Open recent (1-9 or Enter, Esc closes)", ""}}
+	if len(m.paths) == 0 {
+		overlay.Lines = append(overlay.Lines, "No ROMs played yet")
+		return overlay
+	}
+
+	for i, path := range m.paths {
+		overlay.Lines = append(overlay.Lines, fmt.Sprintf("%d  %s  (%s)", i+1, filepath.Base(path), filepath.Dir(path)))
+	}
+	overlay.Highlights = []emulator.Highlight{
+		{Line: 2 + m.selected, Column: 0, Length: len(overlay.Lines[2+m.selected]), Kind: emulator.HighlightCursor},
+	}
+
+	return overlay
+}
@@ -0,0 +1,38 @@
+package ebitenui
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+// RunProgram opens a window playing a ROM translated into Go by the
+// recompile command on machine, for standalone builds of a single game.
+func RunProgram(title string, rom []byte, program core.Program, machine core.Machine, scale, cpuFrequency int) error {
+	chip8 := core.NewChip8WithOptions(core.Options{Machine: machine})
+	chip8.SetProgram(program)
+
+	platform := NewPlatform(scale)
+
+	var audio emulator.Audio
+	audio, err := NewAudio()
+	if err != nil {
+		log.Printf("sound disabled: %v", err)
+		audio = &emulator.MemoryAudio{}
+	}
+
+	engine := emulator.NewEngine(chip8, platform, platform, audio, cpuFrequency)
+	engine.SetCaptureScale(scale)
+	if err := engine.LoadRom(title, rom); err != nil {
+		return err
+	}
+
+	width, height := chip8.VideoSize()
+	ebiten.SetWindowSize(width*scale, height*scale)
+	ebiten.SetWindowTitle(title)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+
+	return ebiten.RunGame(NewGame(engine, platform))
+}
@@ -0,0 +1,190 @@
+package emulator
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Frame is a video buffer after display filters, ready to be presented.
+type Frame struct {
+	Width  int
+	Height int
+	// Pixels holds the intensity of every pixel, from 0 (background) to 1
+	// (foreground).
+	Pixels []float32
+	// Foreground, when set, is the colour of every pixel on machines that
+	// colour their display, such as CHIP-8X, drawn over Background instead
+	// of the palette.
+	Foreground []color.RGBA
+	Background color.RGBA
+}
+
+// Shade returns the colour of pixel i, from the machine's colours when it
+// has some and from palette otherwise.
+func (f Frame) Shade(i int, palette Palette) color.RGBA {
+	if f.Foreground != nil {
+		palette.Colors[0] = f.Background
+		palette.Colors[1] = f.Foreground[i]
+	}
+	return palette.Shade(f.Pixels[i])
+}
+
+// DisplaySettings are the presentation options chosen by the user.
+// Backends apply the ones they support and ignore the rest.
+type DisplaySettings struct {
+	Palette    Palette
+	PixelStyle PixelStyle
+	ScaleMode  ScaleMode
+	Filter     FilterOptions
+	Shader     ShaderOptions
+	Fullscreen bool
+}
+
+func DefaultDisplaySettings() DisplaySettings {
+	return DisplaySettings{
+		Palette:    DefaultPalette(),
+		PixelStyle: PixelSquare,
+		ScaleMode:  ScaleInteger,
+		Filter:     DefaultFilterOptions(),
+		Shader:     DefaultShaderOptions(),
+	}
+}
+
+// Display presents the frames produced by the Engine.
+type Display interface {
+	Present(frame Frame, settings DisplaySettings)
+	// Notify shows a short status message, such as the value of a shader
+	// parameter being tuned.
+	Notify(message string)
+}
+
+// OverlayDisplay is implemented by displays that can draw text on top of
+// the frame, such as the debugger. A nil overlay hides it.
+type OverlayDisplay interface {
+	PresentOverlay(overlay *Overlay)
+}
+
+// Overlay is monospaced text with highlighted ranges of characters.
+type Overlay struct {
+	Lines      []string
+	Highlights []Highlight
+}
+
+type HighlightKind int
+
+const (
+	HighlightRead HighlightKind = iota
+	HighlightWrite
+	HighlightIndex
+	HighlightPC
+	HighlightCursor
+)
+
+// Highlight marks Length characters of a line starting at Column. Later
+// highlights are drawn over earlier ones.
+type Highlight struct {
+	Line   int
+	Column int
+	Length int
+	Kind   HighlightKind
+}
+
+// Input reports the state of the CHIP-8 keypad and of the emulator
+// hotkeys. It is polled once per Engine update.
+type Input interface {
+	// Keypad writes the state of the 16 CHIP-8 keys into keys.
+	Keypad(keys []bool)
+	// Pressed reports whether hotkey went down since the previous update.
+	Pressed(hotkey Hotkey) bool
+}
+
+// TextInput is implemented by inputs that can report typed characters,
+// which the memory editor uses to enter values.
+type TextInput interface {
+	// InputChars returns the characters typed since the previous update.
+	InputChars() []rune
+}
+
+// SecondKeypadInput is implemented by inputs with a second keypad, which
+// CHIP-8X programs read with EXF2 and EXF5.
+type SecondKeypadInput interface {
+	// SecondKeypad writes the state of the 16 keys of the second keypad
+	// into keys.
+	SecondKeypad(keys []bool)
+}
+
+// Audio plays the CHIP-8 buzzer.
+type Audio interface {
+	SetBuzzer(isOn bool)
+}
+
+type Hotkey int
+
+const (
+	HotkeyPause Hotkey = iota
+	HotkeyReset
+	HotkeyPowerCycle
+	HotkeyPalette
+	HotkeyPixelStyle
+	HotkeyFilter
+	HotkeyShader
+	HotkeyShaderParam
+	HotkeyShaderUp
+	HotkeyShaderDown
+	HotkeyScaleMode
+	HotkeyFullscreen
+	HotkeyScreenshot
+	HotkeyRecord
+	HotkeySaveState
+	HotkeyLoadState
+	HotkeyDebugger
+	HotkeyStep
+	HotkeyContinue
+	HotkeyBreakpoint
+	HotkeyMemory
+	HotkeyCursorUp
+	HotkeyCursorDown
+	HotkeyCursorLeft
+	HotkeyCursorRight
+	HotkeyEditTarget
+	// HotkeyRecent opens the list of recently played ROMs. Frontends able
+	// to switch ROMs handle it; Engine ignores it.
+	HotkeyRecent
+)
+
+var hotkeyNames = [...]string{
+	HotkeyPause:       "pause",
+	HotkeyReset:       "reset",
+	HotkeyPowerCycle:  "power-cycle",
+	HotkeyPalette:     "palette",
+	HotkeyPixelStyle:  "pixel-style",
+	HotkeyFilter:      "filter",
+	HotkeyShader:      "shader",
+	HotkeyShaderParam: "shader-param",
+	HotkeyShaderUp:    "shader-up",
+	HotkeyShaderDown:  "shader-down",
+	HotkeyScaleMode:   "scale-mode",
+	HotkeyFullscreen:  "fullscreen",
+	HotkeyScreenshot:  "screenshot",
+	HotkeyRecord:      "record",
+	HotkeySaveState:   "save-state",
+	HotkeyLoadState:   "load-state",
+	HotkeyDebugger:    "debugger",
+	HotkeyStep:        "step",
+	HotkeyContinue:    "continue",
+	HotkeyBreakpoint:  "breakpoint",
+	HotkeyMemory:      "memory",
+	HotkeyCursorUp:    "cursor-up",
+	HotkeyCursorDown:  "cursor-down",
+	HotkeyCursorLeft:  "cursor-left",
+	HotkeyCursorRight: "cursor-right",
+	HotkeyEditTarget:  "edit-target",
+	HotkeyRecent:      "recent",
+}
+
+func (h Hotkey) String() string {
+	if int(h) < len(hotkeyNames) {
+		return hotkeyNames[h]
+	}
+	return fmt.Sprintf("Hotkey(%d)", int(h))
+}
@@ -0,0 +1,40 @@
+package emulator
+
+import (
+	"github.com/mochaeng/G8Emu/internal/cheat"
+)
+
+// SetCheatDir sets where cheat codes are saved per ROM. Without it cheats
+// only last for the session.
+func (e *Engine) SetCheatDir(dir string) {
+	e.cheatDir = dir
+}
+
+// Cheats returns the cheat codes of the loaded ROM.
+func (e *Engine) Cheats() *cheat.Cheats {
+	return e.cheats
+}
+
+// CheatCommand runs a cheat command, see cheat.Cheats.Exec, against the
+// emulated memory. It must be called from the goroutine running Update;
+// other goroutines go through Do.
+func (e *Engine) CheatCommand(command string) (string, error) {
+	return e.cheats.Exec(e.chip8, command)
+}
+
+// Do runs fn at the start of the next Update, for callers on other
+// goroutines such as a console reading commands from stdin.
+func (e *Engine) Do(fn func()) {
+	e.tasks <- fn
+}
+
+func (e *Engine) runTasks() {
+	for {
+		select {
+		case task := <-e.tasks:
+			task()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package emulator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+const (
+	// DISASSEMBLY_LINES is how many instructions the debugger lists before
+	// and after PC.
+	DISASSEMBLY_LINES = 6
+	MEMORY_ROWS       = 8
+	MEMORY_ROW_SIZE   = 8
+)
+
+// ShowDebugger toggles the debugger overlay on displays that support it.
+func (e *Engine) ShowDebugger(isShown bool) {
+	e.showDebugger = isShown
+}
+
+func (e *Engine) IsDebuggerShown() bool {
+	return e.showDebugger
+}
+
+// Step pauses emulation and executes a single instruction.
+func (e *Engine) Step() {
+	e.chip8.Pause()
+	e.chip8.Step()
+	e.lastUpdate = e.now()
+	e.timeAccumulator = 0
+}
+
+// Continue resumes emulation. When paused on a breakpoint, the instruction
+// under it is executed first so the breakpoint does not fire again. Under
+// VIP timing, the cycles left in the frame it paused in are dropped.
+func (e *Engine) Continue() {
+	if !e.chip8.IsPaused() {
+		return
+	}
+
+	if e.breakpoints[e.chip8.PC()] {
+		e.chip8.Step()
+	}
+	e.chip8.Resume()
+	e.lastUpdate = e.now()
+	e.timeAccumulator = 0
+	e.vipCycles = min(e.vipCycles, 0)
+}
+
+// ToggleBreakpoint sets or clears a breakpoint and reports whether it is
+// now set. Emulation pauses before executing an instruction at a
+// breakpoint.
+func (e *Engine) ToggleBreakpoint(addr uint16) bool {
+	if e.breakpoints[addr] {
+		delete(e.breakpoints, addr)
+		return false
+	}
+	e.breakpoints[addr] = true
+	return true
+}
+
+// Breakpoints returns the breakpoint addresses in ascending order.
+func (e *Engine) Breakpoints() []uint16 {
+	addrs := make([]uint16, 0, len(e.breakpoints))
+	for addr := range e.breakpoints {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	return addrs
+}
+
+func (e *Engine) updateDebuggerHotkeys() {
+	if e.input.Pressed(HotkeyDebugger) {
+		e.showDebugger = !e.showDebugger
+	}
+
+	if e.input.Pressed(HotkeyStep) {
+		e.Step()
+	}
+
+	if e.input.Pressed(HotkeyContinue) {
+		e.Continue()
+	}
+
+	// B is also a hex digit for the memory editor.
+	if e.input.Pressed(HotkeyBreakpoint) && !e.memory.isShown {
+		pc := e.chip8.PC()
+		if e.ToggleBreakpoint(pc) {
+			e.display.Notify(fmt.Sprintf("breakpoint set at %03X", pc))
+		} else {
+			e.display.Notify(fmt.Sprintf("breakpoint cleared at %03X", pc))
+		}
+	}
+}
+
+// debuggerLines renders the machine state as text: registers and timers on
+// top, then the disassembly around PC next to a hex dump around I.
+func (e *Engine) debuggerLines() []string {
+	c8 := e.chip8
+	pc, index := c8.PC(), c8.Index()
+
+	state := "RUNNING"
+	if c8.IsPaused() {
+		state = "PAUSED"
+	} else if c8.IsWaitingForKey() {
+		state = "WAITING FOR KEY"
+	}
+
+	lines := []string{
+		fmt.Sprintf("PC %03X  I %03X  SP %X  DT %02X  ST %02X  %s", pc, index, c8.SP(), c8.DelayTimer, c8.SoundTimer, state),
+	}
+
+	registers := c8.Registers()
+	for row := 0; row < 16; row += 8 {
+		var line strings.Builder
+		for i := row; i < row+8; i++ {
+			fmt.Fprintf(&line, "V%X %02X  ", i, registers[i])
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+
+	stack := "Stack:"
+	for _, addr := range c8.CallStack() {
+		stack += fmt.Sprintf(" %03X", addr)
+	}
+	lines = append(lines, stack, "")
+
+	var disassembly []string
+	start := pc - 2*DISASSEMBLY_LINES
+	if pc < 2*DISASSEMBLY_LINES {
+		start = pc % 2
+	}
+	for addr := start; addr <= pc+2*DISASSEMBLY_LINES; addr += 2 {
+		marker := "  "
+		switch {
+		case addr == pc && e.breakpoints[addr]:
+			marker = "*>"
+		case addr == pc:
+			marker = " >"
+		case e.breakpoints[addr]:
+			marker = "* "
+		}
+
+		opcode := c8.PeekOpcode(addr)
+		disassembly = append(disassembly, fmt.Sprintf("%s%03X %04X %-14s", marker, addr&0xFFF, opcode, core.Disassemble(opcode)))
+	}
+
+	memory := []string{"I:"}
+	memoryStart := (index &^ (MEMORY_ROW_SIZE - 1)) - MEMORY_ROW_SIZE*2
+	if index < MEMORY_ROW_SIZE*2 {
+		memoryStart = 0
+	}
+	for row := range uint16(MEMORY_ROWS) {
+		addr := memoryStart + row*MEMORY_ROW_SIZE
+		var line strings.Builder
+		fmt.Fprintf(&line, "%03X", addr&0xFFF)
+		for i := range uint16(MEMORY_ROW_SIZE) {
+			separator := " "
+			if addr+i == index {
+				separator = "["
+			} else if addr+i == index+1 && i > 0 {
+				separator = "]"
+			}
+			fmt.Fprintf(&line, "%s%02X", separator, c8.Peek(addr+i))
+		}
+		if index == addr+MEMORY_ROW_SIZE-1 {
+			line.WriteString("]")
+		}
+		memory = append(memory, line.String())
+	}
+
+	for i := range max(len(disassembly), len(memory)) {
+		var left, right string
+		if i < len(disassembly) {
+			left = disassembly[i]
+		}
+		if i < len(memory) {
+			right = memory[i]
+		}
+		lines = append(lines, fmt.Sprintf("%-28s %s", left, right))
+	}
+
+	return lines
+}
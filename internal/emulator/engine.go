@@ -1,61 +1,250 @@
 package emulator
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/mochaeng/G8Emu/internal/cheat"
+	"github.com/mochaeng/G8Emu/internal/constants"
 	"github.com/mochaeng/G8Emu/internal/core"
 )
 
+// MAX_LATCH_FRAMES is how many frames the display-wait filter holds a
+// frame before falling back to the live video buffer, for programs that
+// never wait on the delay timer.
+const MAX_LATCH_FRAMES = 6
+
+// MAX_CPU_FREQUENCY is the fastest CPU frequency, keeping an instruction
+// at least a microsecond long.
+const MAX_CPU_FREQUENCY = 1_000_000
+
+// Engine drives a Chip8 in real time: it runs cycles at the CPU frequency,
+// ticks the timers at 60Hz, handles hotkeys and hands frames to the
+// Display. It does not depend on any particular frontend.
 type Engine struct {
-	platform        *Platform
 	chip8           *core.Chip8
-	cpuFrequency    int
+	display         Display
+	input           Input
+	audio           Audio
+	now             func() time.Time
 	lastUpdate      time.Time
 	lastTimer       time.Time
 	timeAccumulator time.Duration
 	cycleTime       time.Duration
 
-	pausedKeyPressed bool
+	settings DisplaySettings
+	filter   *pixelFilter
+	// foreground is reused by the frames of machines with colour.
+	foreground []color.RGBA
+
+	shaderParam int
+
+	romName       string
+	romHash       string
+	screenshotDir string
+	captureScale  int
+
+	recorder       *Recorder
+	recordDefaults RecorderOptions
+
+	latchedVideo     [constants.VIDEO_WIDTH * constants.HIRES_VIDEO_HEIGHT]bool
+	hasDrawn         bool
+	framesSinceLatch int
+
+	showDebugger bool
+	breakpoints  map[uint16]bool
+	memory       memoryEditor
+
+	cheats   *cheat.Cheats
+	cheatDir string
+	tasks    chan func()
+
+	stateDir string
+	watcher  *watcher
+
+	timing          Timing
+	vipCycles       int
+	isWaitingVblank bool
 }
 
-func NewGame(platform *Platform, chip8 *core.Chip8, cpuFrequency int) *Engine {
+func NewEngine(chip8 *core.Chip8, display Display, input Input, audio Audio, cpuFrequency int) *Engine {
+	settings := DefaultDisplaySettings()
+	if CheckCpuFrequency(cpuFrequency) != nil {
+		cpuFrequency = constants.DEFAULT_FREQUENCY
+	}
+
 	return &Engine{
-		platform:   platform,
 		chip8:      chip8,
+		display:    display,
+		input:      input,
+		audio:      audio,
+		now:        time.Now,
 		lastUpdate: time.Now(),
 		cycleTime:  time.Second / time.Duration(cpuFrequency),
+		settings:   settings,
+		filter:     newPixelFilter(settings.Filter, constants.VIDEO_WIDTH*constants.VIDEO_HEIGHT),
+
+		screenshotDir:  ".",
+		stateDir:       ".",
+		captureScale:   1,
+		recordDefaults: RecorderOptions{Path: ".gif"},
+		breakpoints:    make(map[uint16]bool),
+		cheats:         &cheat.Cheats{},
+		tasks:          make(chan func(), 16),
+	}
+}
+
+// SetClock replaces time.Now, so that timing can be driven by tests.
+func (e *Engine) SetClock(now func() time.Time) {
+	e.now = now
+	e.lastUpdate = now()
+	e.lastTimer = now()
+}
+
+// LoadRom power cycles the machine with a new program, remembering its name
+// and hash for screenshots and recordings.
+func (e *Engine) LoadRom(name string, data []byte) error {
+	if err := e.chip8.LoadRomBytes(data); err != nil {
+		return err
+	}
+	e.PowerCycle()
+
+	sum := sha1.Sum(data)
+	e.romName = name
+	e.romHash = hex.EncodeToString(sum[:])
+
+	cheats, err := cheat.Load(e.cheatDir, e.romHash)
+	if err != nil {
+		log.Printf("cheats disabled: %v", err)
 	}
+	e.cheats = cheats
+
+	return nil
+}
+
+// CheckCpuFrequency reports CPU frequencies the engine cannot run at.
+func CheckCpuFrequency(cpuFrequency int) error {
+	if cpuFrequency <= 0 || cpuFrequency > MAX_CPU_FREQUENCY {
+		return fmt.Errorf("CPU frequency %d out of range (1 to %d)", cpuFrequency, MAX_CPU_FREQUENCY)
+	}
+	return nil
+}
+
+// SetCpuFrequency changes how many instructions run per second. Frequencies
+// rejected by CheckCpuFrequency are ignored.
+func (e *Engine) SetCpuFrequency(cpuFrequency int) {
+	if CheckCpuFrequency(cpuFrequency) == nil {
+		e.cycleTime = time.Second / time.Duration(cpuFrequency)
+	}
+}
+
+func (e *Engine) CpuFrequency() int {
+	return int(time.Second / e.cycleTime)
+}
+
+func (e *Engine) RomName() string {
+	return e.romName
+}
+
+func (e *Engine) RomHash() string {
+	return e.romHash
+}
+
+func (e *Engine) DisplaySettings() DisplaySettings {
+	return e.settings
+}
+
+func (e *Engine) SetDisplaySettings(settings DisplaySettings) {
+	if settings.Filter != e.settings.Filter {
+		e.filter = newPixelFilter(settings.Filter, len(e.filter.intensity))
+	}
+	e.settings = settings
 }
 
 func (e *Engine) Update() error {
-	e.platform.ProcessInput(e.chip8.Keypad[:])
+	e.runTasks()
+	e.input.Keypad(e.chip8.Keypad[:])
+	if input, ok := e.input.(SecondKeypadInput); ok {
+		input.SecondKeypad(e.chip8.SecondKeypad[:])
+	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		// return ebiten.Termination
+	if e.input.Pressed(HotkeyPause) {
+		if e.chip8.IsPaused() {
+			e.Continue()
+		} else {
+			e.chip8.Pause()
+		}
+	}
+
+	e.updateDisplayHotkeys()
+	e.updateDebuggerHotkeys()
+	e.updateMemoryEditor()
+	e.updateStateHotkeys()
+	e.checkWatch()
+
+	if e.input.Pressed(HotkeyScreenshot) {
+		if paths, err := e.SaveScreenshots(); err != nil {
+			log.Printf("failed to save screenshot: %v", err)
+		} else {
+			log.Printf("saved screenshots %s", strings.Join(paths, ", "))
+		}
 	}
 
-	isPause := ebiten.IsKeyPressed(ebiten.KeyP)
-	if isPause && !e.pausedKeyPressed {
-		e.chip8.TogglePause()
-		e.pausedKeyPressed = true
-	} else if !isPause {
-		e.pausedKeyPressed = false
+	if e.input.Pressed(HotkeyRecord) {
+		e.toggleRecording()
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyR) {
+	if e.input.Pressed(HotkeyPowerCycle) {
+		e.PowerCycle()
+		return nil
+	}
+
+	if e.input.Pressed(HotkeyReset) {
 		e.Reset()
 		return nil
 	}
 
-	currentTime := time.Now()
+	currentTime := e.now()
+	if e.timing == TimingVip {
+		e.lastUpdate = currentTime
+		e.runVip(currentTime)
+	} else {
+		e.runFixed(currentTime)
+	}
+
+	e.cheats.Apply(e.chip8)
+	e.audio.SetBuzzer(e.chip8.SoundTimer > 0 && !e.chip8.IsPaused())
+
+	return nil
+}
+
+// runFixed runs the instructions due since the last update at the CPU
+// frequency, and ticks the timers at 60Hz.
+func (e *Engine) runFixed(currentTime time.Time) {
 	elapsed := currentTime.Sub(e.lastUpdate)
 	e.lastUpdate = currentTime
 	e.timeAccumulator += elapsed
 
+	isDisplayWait := e.settings.Filter.Filter == FilterDisplayWait
 	for e.timeAccumulator >= e.cycleTime {
+		if !e.chip8.IsPaused() && e.breakpoints[e.chip8.PC()] {
+			e.chip8.Pause()
+			e.display.Notify(fmt.Sprintf("breakpoint at %03X", e.chip8.PC()))
+		}
+
 		e.chip8.Cycle()
 		e.timeAccumulator -= e.cycleTime
+
+		if isDisplayWait {
+			e.latchVideo()
+		}
 	}
 
 	if !e.chip8.IsPaused() && currentTime.Sub(e.lastTimer) >= time.Second/60 {
@@ -65,29 +254,257 @@ func (e *Engine) Update() error {
 		if e.chip8.SoundTimer > 0 {
 			e.chip8.SoundTimer--
 		}
-		e.lastTimer = time.Now()
+		e.lastTimer = currentTime
+	}
+}
+
+// Draw filters the current video buffer and presents it.
+func (e *Engine) Draw() {
+	width, height := e.chip8.VideoSize()
+	video := e.presentedVideo()
+
+	if len(e.filter.intensity) != width*height {
+		e.filter = newPixelFilter(e.settings.Filter, width*height)
+	}
+
+	currentTime := e.now()
+	pixels := e.filter.apply(video, currentTime)
+
+	frame := Frame{Width: width, Height: height, Pixels: pixels}
+	if e.chip8.HasColor() {
+		frame.Foreground, frame.Background = e.machineColors(width, height)
+	}
+	e.display.Present(frame, e.settings)
+
+	if overlay, ok := e.display.(OverlayDisplay); ok {
+		switch {
+		case e.memory.isShown:
+			overlay.PresentOverlay(e.memoryOverlay())
+		case e.showDebugger:
+			overlay.PresentOverlay(&Overlay{Lines: e.debuggerLines()})
+		default:
+			overlay.PresentOverlay(nil)
+		}
+	}
+
+	if e.recorder != nil {
+		if err := e.recorder.AddFrame(video, width, height, e.chip8.SoundTimer > 0); err != nil {
+			log.Printf("recording stopped: %v", err)
+			e.StopRecording()
+		}
+	}
+}
+
+func (e *Engine) SetScreenshotDir(dir string) {
+	e.screenshotDir = dir
+}
+
+// SetCaptureScale sets the scale of the scaled screenshots and of hotkey
+// recordings.
+func (e *Engine) SetCaptureScale(scale int) {
+	e.captureScale = max(scale, 1)
+}
+
+// Screenshot encodes the current video buffer as PNG using the active
+// palette, with every CHIP-8 pixel scaled to scale x scale.
+func (e *Engine) Screenshot(scale int) ([]byte, error) {
+	width, height := e.chip8.VideoSize()
+	info := ScreenshotInfo{
+		RomName: e.romName,
+		RomHash: e.romHash,
+		Cycles:  e.chip8.Cycles(),
+	}
+
+	return EncodeScreenshot(e.chip8.Screen(), width, height, scale, e.settings.Palette, info)
+}
+
+// SaveScreenshots writes the current frame at native and capture scale
+// into the screenshot directory and returns the written paths.
+func (e *Engine) SaveScreenshots() ([]string, error) {
+	if err := os.MkdirAll(e.screenshotDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create screenshot directory: %v", err)
 	}
 
+	var paths []string
+	for _, shot := range []struct {
+		suffix string
+		scale  int
+	}{
+		{"-native.png", 1},
+		{".png", e.captureScale},
+	} {
+		data, err := e.Screenshot(shot.scale)
+		if err != nil {
+			return paths, err
+		}
+
+		path := e.capturePath(e.screenshotDir, shot.suffix)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return paths, fmt.Errorf("failed to write screenshot: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// SetRecordDefaults sets the options used by recordings started with the
+// hotkey. Only the extension of Path is used, to pick the format.
+func (e *Engine) SetRecordDefaults(options RecorderOptions) {
+	e.recordDefaults = options
+}
+
+// StartRecording records every presented frame until StopRecording. A
+// zero Palette records with the active palette.
+func (e *Engine) StartRecording(options RecorderOptions) error {
+	if e.recorder != nil {
+		return fmt.Errorf("already recording to %s", e.recorder.Path())
+	}
+
+	if options.Palette.Name == "" {
+		options.Palette = e.settings.Palette
+	}
+
+	recorder, err := NewRecorder(options)
+	if err != nil {
+		return err
+	}
+	e.recorder = recorder
+
 	return nil
 }
 
-func (e *Engine) Draw(screen *ebiten.Image) {
-	e.platform.UpdateDisplay(e.chip8.Video[:])
-	e.platform.Draw(screen)
+func (e *Engine) StopRecording() error {
+	if e.recorder == nil {
+		return nil
+	}
+
+	recorder := e.recorder
+	e.recorder = nil
+
+	return recorder.Close()
+}
+
+func (e *Engine) IsRecording() bool {
+	return e.recorder != nil
 }
 
-func (e *Engine) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return e.platform.Layout(outsideWidth, outsideHeight)
+func (e *Engine) toggleRecording() {
+	if e.recorder != nil {
+		path := e.recorder.Path()
+		if err := e.StopRecording(); err != nil {
+			log.Printf("failed to save recording: %v", err)
+		} else {
+			log.Printf("saved recording %s", path)
+		}
+		return
+	}
+
+	if err := os.MkdirAll(e.screenshotDir, 0o755); err != nil {
+		log.Printf("failed to create recording directory: %v", err)
+		return
+	}
+
+	options := e.recordDefaults
+	options.Path = e.capturePath(e.screenshotDir, filepath.Ext(options.Path))
+	if options.Scale == 0 {
+		options.Scale = e.captureScale
+	}
+
+	if err := e.StartRecording(options); err != nil {
+		log.Printf("failed to start recording: %v", err)
+		return
+	}
+	log.Printf("recording to %s", options.Path)
+}
+
+// CAPTURE_TIME_LAYOUT stamps the names of captures and save states.
+const CAPTURE_TIME_LAYOUT = "20060102-150405"
+
+// capturePath names screenshots, recordings and save states in dir after
+// the ROM and the current time.
+func (e *Engine) capturePath(dir, suffix string) string {
+	return filepath.Join(dir, e.captureName()+"-"+time.Now().Format(CAPTURE_TIME_LAYOUT)+suffix)
+}
+
+func (e *Engine) captureName() string {
+	name := strings.TrimSuffix(filepath.Base(e.romName), filepath.Ext(e.romName))
+	if name == "" || name == "." {
+		name = "g8emu"
+	}
+	return name
+}
+
+// machineColors returns the colour of every pixel and the background of
+// machines that colour their display.
+func (e *Engine) machineColors(width, height int) ([]color.RGBA, color.RGBA) {
+	if len(e.foreground) != width*height {
+		e.foreground = make([]color.RGBA, width*height)
+	}
+
+	for y := range height {
+		for x := range width {
+			e.foreground[y*width+x] = chip8xColors[e.chip8.PixelColor(x, y)]
+		}
+	}
+
+	return e.foreground, chip8xBackgrounds[e.chip8.BackgroundColor()]
 }
 
+// latchVideo captures the video buffer when the program starts waiting for
+// the next frame (reading the delay timer or waiting for a key) after
+// having drawn, which is when a frame is complete.
+func (e *Engine) latchVideo() {
+	opcode := e.chip8.Opcode()
+
+	switch {
+	case opcode&0xF000 == 0xD000 || opcode == 0x00E0:
+		e.hasDrawn = true
+	case e.hasDrawn && (opcode&0xF0FF == 0xF007 || opcode&0xF0FF == 0xF00A):
+		e.latchedVideo = e.chip8.Video
+		e.hasDrawn = false
+		e.framesSinceLatch = 0
+	}
+}
+
+func (e *Engine) presentedVideo() []bool {
+	screen := e.chip8.Screen()
+	if e.settings.Filter.Filter != FilterDisplayWait {
+		return screen
+	}
+
+	e.framesSinceLatch++
+	if e.framesSinceLatch > MAX_LATCH_FRAMES {
+		e.latchedVideo = e.chip8.Video
+	}
+
+	return e.latchedVideo[:len(screen)]
+}
+
+// Reset is a soft reset: the program restarts with the fontset and the ROM
+// copied back into memory and the rest of memory kept.
 func (e *Engine) Reset() {
 	e.chip8.Reset()
+	e.restart()
+}
 
-	e.lastUpdate = time.Now()
-	e.lastTimer = time.Now()
+// PowerCycle restarts the program with memory cleared first.
+func (e *Engine) PowerCycle() {
+	e.chip8.PowerCycle()
+	e.restart()
+}
+
+func (e *Engine) restart() {
+	e.lastUpdate = e.now()
+	e.lastTimer = e.now()
 	e.timeAccumulator = 0
 
-	e.pausedKeyPressed = false
+	e.latchedVideo = e.chip8.Video
+	e.hasDrawn = false
+	e.framesSinceLatch = 0
+
+	e.restartTiming()
+	e.cheats.Rearm()
 }
 
 func (e *Engine) Pause() {
@@ -101,3 +518,69 @@ func (e *Engine) Resume() {
 func (e *Engine) IsPaused() bool {
 	return e.chip8.IsPaused()
 }
+
+// IsWaitingForKey reports whether the program waits for a key press on
+// FX0A, for frontends to show an indicator.
+func (e *Engine) IsWaitingForKey() bool {
+	return e.chip8.IsWaitingForKey()
+}
+
+// updateDisplayHotkeys cycles the presentation settings and tunes the CRT
+// shader at runtime: one hotkey selects a parameter, two others change it.
+func (e *Engine) updateDisplayHotkeys() {
+	settings := e.settings
+
+	if e.input.Pressed(HotkeyPalette) {
+		settings.Palette = NextPalette(settings.Palette)
+	}
+
+	if e.input.Pressed(HotkeyPixelStyle) {
+		settings.PixelStyle = settings.PixelStyle.Next()
+	}
+
+	if e.input.Pressed(HotkeyFilter) {
+		settings.Filter.Filter = settings.Filter.Filter.Next()
+	}
+
+	if e.input.Pressed(HotkeyScaleMode) {
+		settings.ScaleMode = settings.ScaleMode.Next()
+	}
+
+	if e.input.Pressed(HotkeyFullscreen) {
+		settings.Fullscreen = !settings.Fullscreen
+	}
+
+	if e.input.Pressed(HotkeyShader) {
+		settings.Shader.Enabled = !settings.Shader.Enabled
+	}
+
+	name := shaderParams[e.shaderParam]
+	isTuning := false
+
+	if e.input.Pressed(HotkeyShaderParam) {
+		e.shaderParam = (e.shaderParam + 1) % len(shaderParams)
+		name = shaderParams[e.shaderParam]
+		isTuning = true
+	}
+
+	step := 0.05
+	if name == "aberration" {
+		step = 0.25
+	}
+
+	param := settings.Shader.Param(name)
+	if e.input.Pressed(HotkeyShaderUp) {
+		*param += step
+		isTuning = true
+	}
+	if e.input.Pressed(HotkeyShaderDown) {
+		*param = max(0, *param-step)
+		isTuning = true
+	}
+
+	if isTuning {
+		e.display.Notify(fmt.Sprintf("shader %s: %.2f", name, *param))
+	}
+
+	e.SetDisplaySettings(settings)
+}
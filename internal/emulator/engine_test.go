@@ -0,0 +1,205 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/constants"
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+// TEST_FREQUENCY runs an instruction every millisecond.
+const TEST_FREQUENCY = 1000
+
+// testProgram sets the delay timer to 255, stores 42 at 300 and then loops
+// at 20A.
+var testProgram = []byte{
+	0x60, 0xFF, // LD V0, FF
+	0xF0, 0x15, // LD DT, V0
+	0xA3, 0x00, // LD I, 300
+	0x60, 0x2A, // LD V0, 2A
+	0xF0, 0x55, // LD [I], V0
+	0x12, 0x0A, // JP 20A
+}
+
+type testEngine struct {
+	*Engine
+	clock   *ManualClock
+	display *MemoryDisplay
+	input   *MemoryInput
+}
+
+func newTestEngine(t *testing.T) *testEngine {
+	t.Helper()
+
+	te := &testEngine{
+		clock:   NewManualClock(),
+		display: &MemoryDisplay{},
+		input:   &MemoryInput{},
+	}
+	te.Engine = NewEngine(core.NewChip8WithOptions(core.Options{Seed: 1}), te.display, te.input, &MemoryAudio{}, TEST_FREQUENCY)
+	te.SetClock(te.clock.Now)
+
+	if err := te.LoadRom("test.ch8", testProgram); err != nil {
+		t.Fatalf("failed to load ROM: %v", err)
+	}
+
+	return te
+}
+
+// advance moves the clock forward and runs an update.
+func (te *testEngine) advance(t *testing.T, d time.Duration) {
+	t.Helper()
+
+	te.clock.Advance(d)
+	if err := te.Update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+}
+
+func TestEngineRunsCyclesAtFrequency(t *testing.T) {
+	te := newTestEngine(t)
+
+	te.advance(t, 100*time.Millisecond)
+	if cycles := te.chip8.Cycles(); cycles != 100 {
+		t.Errorf("%d cycles after 100ms at %dHz, expected 100", cycles, TEST_FREQUENCY)
+	}
+
+	te.advance(t, 2500*time.Microsecond)
+	te.advance(t, 2500*time.Microsecond)
+	if cycles := te.chip8.Cycles(); cycles != 105 {
+		t.Errorf("%d cycles after 105ms, expected 105", cycles)
+	}
+}
+
+func TestEngineTicksTimersAt60Hz(t *testing.T) {
+	te := newTestEngine(t)
+
+	te.advance(t, 10*time.Millisecond)
+	if te.chip8.DelayTimer != 255 {
+		t.Fatalf("delay timer %d before the first tick, expected 255", te.chip8.DelayTimer)
+	}
+
+	for range 30 {
+		te.advance(t, time.Second/60)
+	}
+	if te.chip8.DelayTimer != 255-30 {
+		t.Errorf("delay timer %d after 30 frames, expected %d", te.chip8.DelayTimer, 255-30)
+	}
+}
+
+func TestEnginePauseFreezesCyclesAndTimers(t *testing.T) {
+	te := newTestEngine(t)
+	te.advance(t, 20*time.Millisecond)
+
+	te.input.Press(HotkeyPause)
+	te.advance(t, time.Second/60)
+	if !te.IsPaused() {
+		t.Fatal("not paused by the pause hotkey")
+	}
+
+	cycles, delay := te.chip8.Cycles(), te.chip8.DelayTimer
+	for range 10 {
+		te.advance(t, time.Second/60)
+	}
+	if te.chip8.Cycles() != cycles || te.chip8.DelayTimer != delay {
+		t.Errorf("%d cycles and delay timer %d while paused, expected %d and %d", te.chip8.Cycles(), te.chip8.DelayTimer, cycles, delay)
+	}
+
+	// Time spent paused is not caught up after resuming.
+	te.input.Press(HotkeyPause)
+	te.advance(t, time.Second/60)
+	te.advance(t, 10*time.Millisecond)
+	if te.IsPaused() || te.chip8.Cycles() != cycles+10 {
+		t.Errorf("%d cycles 10ms after resuming, expected %d", te.chip8.Cycles(), cycles+10)
+	}
+}
+
+func TestEngineResetHotkeys(t *testing.T) {
+	tests := []struct {
+		hotkey   Hotkey
+		expected uint8
+	}{
+		{HotkeyReset, 0x2A},
+		{HotkeyPowerCycle, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.hotkey.String(), func(t *testing.T) {
+			te := newTestEngine(t)
+			te.advance(t, 20*time.Millisecond)
+			if value := te.chip8.Peek(0x300); value != 0x2A {
+				t.Fatalf("program stored %02X at 300, expected 2A", value)
+			}
+
+			te.input.Press(test.hotkey)
+			te.advance(t, time.Millisecond)
+
+			if value := te.chip8.Peek(0x300); value != test.expected {
+				t.Errorf("%02X at 300, expected %02X", value, test.expected)
+			}
+			if value := te.chip8.Peek(0x200); value != testProgram[0] {
+				t.Errorf("%02X at 200, expected the ROM copied back", value)
+			}
+			if te.chip8.PC() != core.START_ADDRESS || te.chip8.Cycles() != 0 {
+				t.Errorf("PC %03X after %d cycles, expected a restart", te.chip8.PC(), te.chip8.Cycles())
+			}
+		})
+	}
+}
+
+func TestEngineBreakpointPauses(t *testing.T) {
+	te := newTestEngine(t)
+	te.ToggleBreakpoint(0x208)
+
+	te.advance(t, 100*time.Millisecond)
+
+	if !te.IsPaused() || te.chip8.PC() != 0x208 {
+		t.Fatalf("paused %t at %03X, expected a pause at 208", te.IsPaused(), te.chip8.PC())
+	}
+	if te.chip8.Peek(0x300) != 0x00 {
+		t.Errorf("the instruction under the breakpoint ran")
+	}
+	if len(te.display.Messages) == 0 || te.display.Messages[len(te.display.Messages)-1] != "breakpoint at 208" {
+		t.Errorf("messages %q, expected the breakpoint to be reported", te.display.Messages)
+	}
+
+	te.Continue()
+	te.advance(t, 10*time.Millisecond)
+	if te.IsPaused() || te.chip8.Peek(0x300) != 0x2A {
+		t.Errorf("program did not continue past the breakpoint")
+	}
+}
+
+func TestEngineRejectsInvalidFrequencies(t *testing.T) {
+	for _, frequency := range []int{0, -60, MAX_CPU_FREQUENCY + 1} {
+		e := NewEngine(core.NewChip8WithOptions(core.Options{Seed: 1}), &MemoryDisplay{}, &MemoryInput{}, &MemoryAudio{}, frequency)
+		if e.CpuFrequency() != constants.DEFAULT_FREQUENCY {
+			t.Errorf("running at %dHz when created at %dHz, expected %dHz", e.CpuFrequency(), frequency, constants.DEFAULT_FREQUENCY)
+		}
+
+		e.SetCpuFrequency(TEST_FREQUENCY)
+		e.SetCpuFrequency(frequency)
+		if e.CpuFrequency() != TEST_FREQUENCY {
+			t.Errorf("running at %dHz after setting %dHz, expected %dHz", e.CpuFrequency(), frequency, TEST_FREQUENCY)
+		}
+	}
+}
+
+func TestEnginePeekMemoryClampsLength(t *testing.T) {
+	te := newTestEngine(t)
+
+	for _, test := range []struct{ length, expected int }{
+		{-1, 0},
+		{0, 0},
+		{16, 16},
+		{1 << 20, te.chip8.MemorySize()},
+	} {
+		if data := te.PeekMemory(0x200, test.length); len(data) != test.expected {
+			t.Errorf("%d bytes peeked for length %d, expected %d", len(data), test.length, test.expected)
+		}
+		if access := te.MemoryAccess(0x200, test.length); len(access) != test.expected {
+			t.Errorf("%d access flags for length %d, expected %d", len(access), test.length, test.expected)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DisplayFilter smooths the flicker caused by CHIP-8 programs erasing and
+// redrawing sprites with XOR. Filters only change what is presented, the
+// emulated video buffer is left untouched.
+type DisplayFilter int
+
+const (
+	FilterNone DisplayFilter = iota
+	// FilterDecay fades pixels out over FadeTime after they are turned off.
+	FilterDecay
+	// FilterBlend averages the last BlendFrames frames.
+	FilterBlend
+	// FilterDisplayWait only presents the video buffer once the program
+	// waits for the next frame, hiding half drawn frames.
+	FilterDisplayWait
+)
+
+var displayFilterNames = [...]string{
+	FilterNone:        "none",
+	FilterDecay:       "decay",
+	FilterBlend:       "blend",
+	FilterDisplayWait: "wait",
+}
+
+const (
+	DEFAULT_FADE_TIME    = 100 * time.Millisecond
+	DEFAULT_BLEND_FRAMES = 3
+	MAX_BLEND_FRAMES     = 16
+)
+
+func (f DisplayFilter) String() string {
+	if int(f) < len(displayFilterNames) {
+		return displayFilterNames[f]
+	}
+	return fmt.Sprintf("DisplayFilter(%d)", int(f))
+}
+
+func (f DisplayFilter) Next() DisplayFilter {
+	return (f + 1) % DisplayFilter(len(displayFilterNames))
+}
+
+func ParseDisplayFilter(name string) (DisplayFilter, error) {
+	for i, filterName := range displayFilterNames {
+		if filterName == name {
+			return DisplayFilter(i), nil
+		}
+	}
+	return FilterNone, fmt.Errorf("unknown display filter %q (available: %s)", name, strings.Join(displayFilterNames[:], ", "))
+}
+
+type FilterOptions struct {
+	Filter      DisplayFilter
+	FadeTime    time.Duration
+	BlendFrames int
+}
+
+func DefaultFilterOptions() FilterOptions {
+	return FilterOptions{
+		Filter:      FilterNone,
+		FadeTime:    DEFAULT_FADE_TIME,
+		BlendFrames: DEFAULT_BLEND_FRAMES,
+	}
+}
+
+// pixelFilter turns video buffers into per-pixel intensities between 0
+// (background) and 1 (foreground).
+type pixelFilter struct {
+	options   FilterOptions
+	intensity []float32
+	history   [][]bool
+	next      int
+	lastApply time.Time
+}
+
+func newPixelFilter(options FilterOptions, size int) *pixelFilter {
+	options.BlendFrames = min(max(options.BlendFrames, 1), MAX_BLEND_FRAMES)
+	if options.FadeTime <= 0 {
+		options.FadeTime = DEFAULT_FADE_TIME
+	}
+
+	f := &pixelFilter{
+		options:   options,
+		intensity: make([]float32, size),
+	}
+
+	if options.Filter == FilterBlend {
+		f.history = make([][]bool, options.BlendFrames)
+		for i := range f.history {
+			f.history[i] = make([]bool, size)
+		}
+	}
+
+	return f
+}
+
+func (f *pixelFilter) apply(videoBuffer []bool, now time.Time) []float32 {
+	var elapsed time.Duration
+	if !f.lastApply.IsZero() {
+		elapsed = now.Sub(f.lastApply)
+	}
+	f.lastApply = now
+
+	switch f.options.Filter {
+	case FilterDecay:
+		fade := float32(elapsed) / float32(f.options.FadeTime)
+		for i, isOn := range videoBuffer {
+			if isOn {
+				f.intensity[i] = 1
+			} else {
+				f.intensity[i] = max(0, f.intensity[i]-fade)
+			}
+		}
+
+	case FilterBlend:
+		copy(f.history[f.next], videoBuffer)
+		f.next = (f.next + 1) % len(f.history)
+
+		for i := range videoBuffer {
+			count := 0
+			for _, frame := range f.history {
+				if frame[i] {
+					count++
+				}
+			}
+			f.intensity[i] = float32(count) / float32(len(f.history))
+		}
+
+	default:
+		for i, isOn := range videoBuffer {
+			if isOn {
+				f.intensity[i] = 1
+			} else {
+				f.intensity[i] = 0
+			}
+		}
+	}
+
+	return f.intensity
+}
@@ -0,0 +1,84 @@
+package emulator
+
+import "time"
+
+// MemoryDisplay is a Display that keeps the last presented frame in
+// memory, for tests and headless runs.
+type MemoryDisplay struct {
+	Frames   int
+	Last     Frame
+	Settings DisplaySettings
+	Messages []string
+}
+
+func (d *MemoryDisplay) Present(frame Frame, settings DisplaySettings) {
+	d.Frames++
+	d.Last.Width = frame.Width
+	d.Last.Height = frame.Height
+	d.Last.Pixels = append(d.Last.Pixels[:0], frame.Pixels...)
+	d.Settings = settings
+}
+
+func (d *MemoryDisplay) Notify(message string) {
+	d.Messages = append(d.Messages, message)
+}
+
+// IsPixelOn reports whether the pixel at x, y of the last frame is lit.
+func (d *MemoryDisplay) IsPixelOn(x, y int) bool {
+	return d.Last.Pixels[y*d.Last.Width+x] > 0
+}
+
+// MemoryInput is an Input driven by code: keys are set directly and
+// hotkeys queued with Press are reported on the next update.
+type MemoryInput struct {
+	Keys    [16]bool
+	pressed map[Hotkey]bool
+}
+
+func (i *MemoryInput) Keypad(keys []bool) {
+	copy(keys, i.Keys[:])
+}
+
+func (i *MemoryInput) Press(hotkey Hotkey) {
+	if i.pressed == nil {
+		i.pressed = make(map[Hotkey]bool)
+	}
+	i.pressed[hotkey] = true
+}
+
+// Pressed consumes a queued hotkey.
+func (i *MemoryInput) Pressed(hotkey Hotkey) bool {
+	isPressed := i.pressed[hotkey]
+	delete(i.pressed, hotkey)
+	return isPressed
+}
+
+// MemoryAudio records the buzzer state instead of playing it.
+type MemoryAudio struct {
+	IsBuzzing bool
+	Starts    int
+}
+
+func (a *MemoryAudio) SetBuzzer(isOn bool) {
+	if isOn && !a.IsBuzzing {
+		a.Starts++
+	}
+	a.IsBuzzing = isOn
+}
+
+// ManualClock is a clock for Engine.SetClock that only moves when told to.
+type ManualClock struct {
+	now time.Time
+}
+
+func NewManualClock() *ManualClock {
+	return &ManualClock{now: time.Unix(0, 0)}
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
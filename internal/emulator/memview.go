@@ -0,0 +1,369 @@
+package emulator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	MEMORY_VIEW_ROWS  = 12
+	MEMORY_VIEW_WIDTH = 16
+	// INDEX_REGION_SIZE is how many bytes from I are highlighted, the most
+	// a sprite or FX55/FX65 can reach.
+	INDEX_REGION_SIZE = 16
+	// RECENT_ACCESS_TIME is how long reads and writes stay highlighted,
+	// measured in emulated time.
+	RECENT_ACCESS_TIME = time.Second
+)
+
+// MemoryAccess flags the recent accesses of a memory address.
+type MemoryAccess uint8
+
+const (
+	AccessRead MemoryAccess = 1 << iota
+	AccessWrite
+)
+
+// Registers that can be edited besides V0-VF, in the order the memory
+// editor's register cursor visits them.
+var editableRegisters = []string{"i", "pc", "dt", "st"}
+
+// MachineState is a snapshot of the CPU registers.
+type MachineState struct {
+	V     [16]uint8
+	I     uint16
+	PC    uint16
+	SP    uint8
+	DT    uint8
+	ST    uint8
+	Stack []uint16
+	// IsWaitingKey is set while FX0A waits for a key.
+	IsWaitingKey bool
+}
+
+// memoryEditor is the state of the memory viewer panel. The cursor points
+// either into memory or, when isRegisters is set, at a register: V0-VF
+// followed by editableRegisters.
+type memoryEditor struct {
+	isShown     bool
+	isRegisters bool
+	cursor      uint16
+	register    int
+	top         uint16
+	digits      int
+}
+
+func (e *Engine) State() MachineState {
+	c8 := e.chip8
+	return MachineState{
+		V:     c8.Registers(),
+		I:     c8.Index(),
+		PC:    c8.PC(),
+		SP:    c8.SP(),
+		DT:    c8.DelayTimer,
+		ST:    c8.SoundTimer,
+		Stack: c8.CallStack(),
+
+		IsWaitingKey: c8.IsWaitingForKey(),
+	}
+}
+
+// PeekMemory returns a copy of length bytes of memory starting at start,
+// wrapping around the end of the address space. The length is clamped to
+// the size of memory.
+func (e *Engine) PeekMemory(start uint16, length int) []byte {
+	data := make([]byte, e.clampMemoryLength(length))
+	for i := range data {
+		data[i] = e.chip8.Peek(start + uint16(i))
+	}
+	return data
+}
+
+func (e *Engine) PokeMemory(addr uint16, value uint8) {
+	e.chip8.Poke(addr, value)
+}
+
+// MemoryAccess reports which of length bytes from start were read or
+// written by the program within RECENT_ACCESS_TIME. The length is clamped
+// like for PeekMemory.
+func (e *Engine) MemoryAccess(start uint16, length int) []MemoryAccess {
+	access := make([]MemoryAccess, e.clampMemoryLength(length))
+	for i := range access {
+		access[i] = e.recentAccess(start + uint16(i))
+	}
+	return access
+}
+
+func (e *Engine) clampMemoryLength(length int) int {
+	return min(max(length, 0), e.chip8.MemorySize())
+}
+
+func (e *Engine) recentAccess(addr uint16) MemoryAccess {
+	window := uint64(RECENT_ACCESS_TIME / e.cycleTime)
+	cycles := e.chip8.Cycles()
+	read, write := e.chip8.LastAccess(addr)
+
+	var access MemoryAccess
+	if read != 0 && cycles-read < window {
+		access |= AccessRead
+	}
+	if write != 0 && cycles-write < window {
+		access |= AccessWrite
+	}
+	return access
+}
+
+// SetRegister changes a register by name: v0-vf, i, pc, dt or st.
+func (e *Engine) SetRegister(name string, value int) error {
+	c8 := e.chip8
+	name = strings.ToLower(name)
+
+	if len(name) == 2 && name[0] == 'v' {
+		register, err := strconv.ParseUint(name[1:], 16, 4)
+		if err != nil {
+			return fmt.Errorf("unknown register %q", name)
+		}
+		c8.SetRegister(int(register), uint8(value))
+		return nil
+	}
+
+	switch name {
+	case "i":
+		c8.SetIndex(uint16(value))
+	case "pc":
+		c8.SetPC(uint16(value))
+	case "dt":
+		c8.DelayTimer = uint8(value)
+	case "st":
+		c8.SoundTimer = uint8(value)
+	default:
+		return fmt.Errorf("unknown register %q", name)
+	}
+
+	return nil
+}
+
+func (e *Engine) ShowMemory(isShown bool) {
+	if isShown && !e.memory.isShown {
+		e.memory.cursor = e.chip8.PC()
+		e.memory.top = uint16(max(0, int(e.memory.cursor&^(MEMORY_VIEW_WIDTH-1))-4*MEMORY_VIEW_WIDTH))
+		e.memory.digits = 0
+	}
+	e.memory.isShown = isShown
+}
+
+func (e *Engine) IsMemoryShown() bool {
+	return e.memory.isShown
+}
+
+// updateMemoryEditor handles the memory viewer keys. While the viewer is
+// open the keyboard edits memory instead of driving the CHIP-8 keypad.
+func (e *Engine) updateMemoryEditor() {
+	m := &e.memory
+
+	if e.input.Pressed(HotkeyMemory) {
+		e.ShowMemory(!m.isShown)
+	}
+	if !m.isShown {
+		return
+	}
+
+	for i := range e.chip8.Keypad {
+		e.chip8.Keypad[i] = false
+	}
+
+	if e.input.Pressed(HotkeyEditTarget) {
+		m.isRegisters = !m.isRegisters
+		m.digits = 0
+	}
+
+	registerCount := 16 + len(editableRegisters)
+	moves := []struct {
+		hotkey           Hotkey
+		memory, register int
+	}{
+		{HotkeyCursorLeft, -1, -1},
+		{HotkeyCursorRight, 1, 1},
+		{HotkeyCursorUp, -MEMORY_VIEW_WIDTH, -8},
+		{HotkeyCursorDown, MEMORY_VIEW_WIDTH, 8},
+	}
+	for _, move := range moves {
+		if !e.input.Pressed(move.hotkey) {
+			continue
+		}
+		m.digits = 0
+		if m.isRegisters {
+			m.register = (m.register + move.register + registerCount) % registerCount
+		} else {
+			m.cursor = uint16(int(m.cursor)+move.memory) % uint16(e.chip8.MemorySize())
+		}
+	}
+
+	textInput, ok := e.input.(TextInput)
+	if !ok {
+		return
+	}
+	for _, char := range textInput.InputChars() {
+		digit, err := strconv.ParseUint(string(unicode.ToLower(char)), 16, 4)
+		if err == nil {
+			e.editDigit(int(digit))
+		}
+	}
+}
+
+// editDigit shifts a hex digit into the value under the cursor. Bytes move
+// the cursor on after two digits, I and PC after three.
+func (e *Engine) editDigit(digit int) {
+	m := &e.memory
+	m.digits++
+
+	if !m.isRegisters {
+		value := e.chip8.Peek(m.cursor)<<4 | uint8(digit)
+		e.chip8.Poke(m.cursor, value)
+		if m.digits == 2 {
+			m.cursor = (m.cursor + 1) % uint16(e.chip8.MemorySize())
+			m.digits = 0
+		}
+		return
+	}
+
+	name, value, digits := e.registerValue(m.register)
+	e.SetRegister(name, value<<4|digit)
+	if m.digits == digits {
+		m.register = (m.register + 1) % (16 + len(editableRegisters))
+		m.digits = 0
+	}
+}
+
+// registerValue returns the name, value and number of hex digits of the
+// register at an editor position.
+func (e *Engine) registerValue(position int) (string, int, int) {
+	state := e.State()
+	if position < 16 {
+		return fmt.Sprintf("v%x", position), int(state.V[position]), 2
+	}
+
+	switch name := editableRegisters[position-16]; name {
+	case "i":
+		return name, int(state.I), 3
+	case "pc":
+		return name, int(state.PC), 3
+	case "dt":
+		return name, int(state.DT), 2
+	default:
+		return name, int(state.ST), 2
+	}
+}
+
+// memoryOverlay renders a hex and ASCII dump around the cursor followed by
+// the registers, highlighting the cursor, PC, the region at I and recent
+// accesses.
+func (e *Engine) memoryOverlay() *Overlay {
+	m := &e.memory
+	state := e.State()
+	overlay := &Overlay{}
+
+	rowsSize := uint16(MEMORY_VIEW_ROWS * MEMORY_VIEW_WIDTH)
+	cursorRow := m.cursor &^ (MEMORY_VIEW_WIDTH - 1)
+	if cursorRow < m.top {
+		m.top = cursorRow
+	} else if cursorRow >= m.top+rowsSize {
+		m.top = cursorRow + MEMORY_VIEW_WIDTH - rowsSize
+	}
+
+	add := func(line string) int {
+		overlay.Lines = append(overlay.Lines, line)
+		return len(overlay.Lines) - 1
+	}
+	highlight := func(line, column, length int, kind HighlightKind) {
+		overlay.Highlights = append(overlay.Highlights, Highlight{Line: line, Column: column, Length: length, Kind: kind})
+	}
+
+	add(fmt.Sprintf("MEMORY %03X  read  written  I  PC", m.cursor))
+	header := "    "
+	for i := range MEMORY_VIEW_WIDTH {
+		header += fmt.Sprintf("%X  ", i)
+	}
+	add(strings.TrimRight(header, " "))
+	legend := len(overlay.Lines) - 2
+	highlight(legend, 12, 4, HighlightRead)
+	highlight(legend, 18, 7, HighlightWrite)
+	highlight(legend, 27, 1, HighlightIndex)
+	highlight(legend, 30, 2, HighlightPC)
+
+	asciiColumn := 4 + MEMORY_VIEW_WIDTH*3 + 1
+	var cursorHighlight *Highlight
+	for row := range uint16(MEMORY_VIEW_ROWS) {
+		start := m.top + row*MEMORY_VIEW_WIDTH
+		data := e.PeekMemory(start, MEMORY_VIEW_WIDTH)
+		access := e.MemoryAccess(start, MEMORY_VIEW_WIDTH)
+
+		var hex, ascii strings.Builder
+		for _, value := range data {
+			fmt.Fprintf(&hex, "%02X ", value)
+			if value >= 0x20 && value < 0x7F {
+				ascii.WriteByte(value)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		line := add(fmt.Sprintf("%03X %s %s", start, hex.String(), ascii.String()))
+
+		for i := range uint16(MEMORY_VIEW_WIDTH) {
+			addr := start + i
+			column := 4 + int(i)*3
+			kinds := []HighlightKind{}
+			if access[i]&AccessRead != 0 {
+				kinds = append(kinds, HighlightRead)
+			}
+			if access[i]&AccessWrite != 0 {
+				kinds = append(kinds, HighlightWrite)
+			}
+			if addr >= state.I && addr < state.I+INDEX_REGION_SIZE {
+				kinds = append(kinds, HighlightIndex)
+			}
+			if addr == state.PC || addr == state.PC+1 {
+				kinds = append(kinds, HighlightPC)
+			}
+			for _, kind := range kinds {
+				highlight(line, column, 2, kind)
+				highlight(line, asciiColumn+int(i), 1, kind)
+			}
+
+			if !m.isRegisters && addr == m.cursor {
+				cursorHighlight = &Highlight{Line: line, Column: column, Length: 2, Kind: HighlightCursor}
+			}
+		}
+	}
+
+	add("")
+	for row := 0; row < 16; row += 8 {
+		var registers strings.Builder
+		for i := row; i < row+8; i++ {
+			fmt.Fprintf(&registers, "V%X %02X  ", i, state.V[i])
+		}
+		line := add(strings.TrimRight(registers.String(), " "))
+
+		if m.isRegisters && m.register >= row && m.register < row+8 {
+			cursorHighlight = &Highlight{Line: line, Column: (m.register-row)*7 + 3, Length: 2, Kind: HighlightCursor}
+		}
+	}
+
+	line := add(fmt.Sprintf("I  %03X  PC %03X  DT %02X  ST %02X", state.I, state.PC, state.DT, state.ST))
+	if m.isRegisters && m.register >= 16 {
+		columns := []struct{ column, length int }{{3, 3}, {11, 3}, {19, 2}, {26, 2}}
+		field := columns[m.register-16]
+		cursorHighlight = &Highlight{Line: line, Column: field.column, Length: field.length, Kind: HighlightCursor}
+	}
+
+	add("Arrows: move  0-F: edit  Tab: memory/registers")
+
+	if cursorHighlight != nil {
+		overlay.Highlights = append(overlay.Highlights, *cursorHighlight)
+	}
+
+	return overlay
+}
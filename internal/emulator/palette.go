@@ -0,0 +1,219 @@
+package emulator
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+// Palette maps pixel values to colours. Index 0 is the background and
+// index 1 the foreground; indexes 2 and 3 are used by XO-CHIP's second
+// plane and by pixels set in both planes.
+type Palette struct {
+	Name   string
+	Colors [4]color.RGBA
+}
+
+var palettes = []Palette{
+	{
+		Name: "classic",
+		Colors: [4]color.RGBA{
+			{0x00, 0x00, 0x00, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF},
+			{0xAA, 0xAA, 0xAA, 0xFF}, {0x55, 0x55, 0x55, 0xFF},
+		},
+	},
+	{
+		Name: "amber",
+		Colors: [4]color.RGBA{
+			{0x1A, 0x0F, 0x00, 0xFF}, {0xFF, 0xB0, 0x00, 0xFF},
+			{0xCC, 0x70, 0x00, 0xFF}, {0x66, 0x3C, 0x00, 0xFF},
+		},
+	},
+	{
+		Name: "green",
+		Colors: [4]color.RGBA{
+			{0x0A, 0x14, 0x0A, 0xFF}, {0x33, 0xFF, 0x33, 0xFF},
+			{0x1F, 0x9E, 0x1F, 0xFF}, {0x0F, 0x4F, 0x0F, 0xFF},
+		},
+	},
+	{
+		Name: "gameboy",
+		Colors: [4]color.RGBA{
+			{0x9B, 0xBC, 0x0F, 0xFF}, {0x0F, 0x38, 0x0F, 0xFF},
+			{0x30, 0x62, 0x30, 0xFF}, {0x8B, 0xAC, 0x0F, 0xFF},
+		},
+	},
+	{
+		Name: "octo",
+		Colors: [4]color.RGBA{
+			{0x99, 0x66, 0x00, 0xFF}, {0xFF, 0xCC, 0x00, 0xFF},
+			{0xFF, 0x66, 0x00, 0xFF}, {0x66, 0x22, 0x00, 0xFF},
+		},
+	},
+}
+
+// chip8xColors are the foreground colours of the VP-590 colour board used
+// by CHIP-8X, indexed by core's COLOR_ constants, and chip8xBackgrounds
+// its background colours, indexed by the BACKGROUND_ constants.
+var (
+	chip8xColors = [8]color.RGBA{
+		core.COLOR_BLACK:  {0x00, 0x00, 0x00, 0xFF},
+		core.COLOR_RED:    {0xFF, 0x00, 0x00, 0xFF},
+		core.COLOR_BLUE:   {0x00, 0x00, 0xFF, 0xFF},
+		core.COLOR_VIOLET: {0xFF, 0x00, 0xFF, 0xFF},
+		core.COLOR_GREEN:  {0x00, 0xFF, 0x00, 0xFF},
+		core.COLOR_YELLOW: {0xFF, 0xFF, 0x00, 0xFF},
+		core.COLOR_AQUA:   {0x00, 0xFF, 0xFF, 0xFF},
+		core.COLOR_WHITE:  {0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	chip8xBackgrounds = [4]color.RGBA{
+		core.BACKGROUND_BLUE:  {0x00, 0x00, 0x80, 0xFF},
+		core.BACKGROUND_BLACK: {0x00, 0x00, 0x00, 0xFF},
+		core.BACKGROUND_GREEN: {0x00, 0x80, 0x00, 0xFF},
+		core.BACKGROUND_RED:   {0x80, 0x00, 0x00, 0xFF},
+	}
+)
+
+func DefaultPalette() Palette {
+	return palettes[0]
+}
+
+func PaletteNames() []string {
+	names := make([]string, len(palettes))
+	for i, palette := range palettes {
+		names[i] = palette.Name
+	}
+	return names
+}
+
+func LookupPalette(name string) (Palette, bool) {
+	for _, palette := range palettes {
+		if palette.Name == name {
+			return palette, true
+		}
+	}
+	return Palette{}, false
+}
+
+// NextPalette returns the named palette following current, wrapping
+// around. Custom palettes are followed by the first named one.
+func NextPalette(current Palette) Palette {
+	for i, palette := range palettes {
+		if palette.Name == current.Name {
+			return palettes[(i+1)%len(palettes)]
+		}
+	}
+	return palettes[0]
+}
+
+// ParsePalette accepts either a palette name or a comma separated list of
+// two to four hex colours, e.g. "#000000,#FFB000".
+func ParsePalette(spec string) (Palette, error) {
+	if palette, ok := LookupPalette(spec); ok {
+		return palette, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) < 2 || len(parts) > 4 {
+		return Palette{}, fmt.Errorf("unknown palette %q (available: %s)", spec, strings.Join(PaletteNames(), ", "))
+	}
+
+	palette := DefaultPalette()
+	palette.Name = "custom"
+	for i, part := range parts {
+		c, err := ParseHexColor(part)
+		if err != nil {
+			return Palette{}, err
+		}
+		palette.Colors[i] = c
+	}
+
+	// Two colour palettes get their XO-CHIP entries blended from the
+	// background and foreground.
+	if len(parts) == 2 {
+		palette.Colors[2] = blendColor(palette.Colors[0], palette.Colors[1], 2.0/3)
+		palette.Colors[3] = blendColor(palette.Colors[0], palette.Colors[1], 1.0/3)
+	}
+
+	return palette, nil
+}
+
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q: expected #RRGGBB", s)
+	}
+
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q: %v", s, err)
+	}
+
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xFF}, nil
+}
+
+func (p Palette) Color(index uint8) color.RGBA {
+	return p.Colors[index&0x3]
+}
+
+// Shade returns the colour of a pixel with the given intensity, blending
+// from the background (0) to the foreground (1).
+func (p Palette) Shade(intensity float32) color.RGBA {
+	switch {
+	case intensity <= 0:
+		return p.Colors[0]
+	case intensity >= 1:
+		return p.Colors[1]
+	}
+	return blendColor(p.Colors[0], p.Colors[1], float64(intensity))
+}
+
+// GapColor is used between pixels by the grid and LED pixel styles.
+func (p Palette) GapColor() color.RGBA {
+	return blendColor(color.RGBA{0x00, 0x00, 0x00, 0xFF}, p.Colors[0], 0.6)
+}
+
+func blendColor(from, to color.RGBA, t float64) color.RGBA {
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{lerp(from.R, to.R), lerp(from.G, to.G), lerp(from.B, to.B), 0xFF}
+}
+
+// PixelStyle controls how each CHIP-8 pixel is drawn once scaled up.
+type PixelStyle int
+
+const (
+	PixelSquare PixelStyle = iota
+	PixelGrid
+	PixelLED
+)
+
+var pixelStyleNames = [...]string{
+	PixelSquare: "square",
+	PixelGrid:   "grid",
+	PixelLED:    "led",
+}
+
+func (s PixelStyle) String() string {
+	if int(s) < len(pixelStyleNames) {
+		return pixelStyleNames[s]
+	}
+	return fmt.Sprintf("PixelStyle(%d)", int(s))
+}
+
+func (s PixelStyle) Next() PixelStyle {
+	return (s + 1) % PixelStyle(len(pixelStyleNames))
+}
+
+func ParsePixelStyle(name string) (PixelStyle, error) {
+	for i, styleName := range pixelStyleNames {
+		if styleName == name {
+			return PixelStyle(i), nil
+		}
+	}
+	return PixelSquare, fmt.Errorf("unknown pixel style %q (available: %s)", name, strings.Join(pixelStyleNames[:], ", "))
+}
@@ -0,0 +1,380 @@
+package emulator
+
+import (
+	"bufio"
+	"compress/lzw"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FRAME_RATE        = 60
+	AUDIO_SAMPLE_RATE = 44100
+	BUZZER_FREQUENCY  = 440
+
+	// GIF_LZW_MIN_CODE_SIZE is the smallest code size GIF allows, enough
+	// for the two colours of a frame.
+	GIF_LZW_MIN_CODE_SIZE = 2
+)
+
+// RecorderOptions configures a gameplay recording. The format is picked
+// from the extension of Path: ".gif" for an animated GIF or ".y4m" for an
+// uncompressed YUV4MPEG2 stream that video tools such as ffmpeg accept.
+type RecorderOptions struct {
+	Path  string
+	Scale int
+	// FrameSkip drops that many frames after every captured one.
+	FrameSkip int
+	// Audio additionally writes the buzzer to a WAV file next to Path.
+	Audio   bool
+	Palette Palette
+}
+
+// Recorder captures presented frames until it is closed.
+type Recorder struct {
+	options RecorderOptions
+	frame   int
+
+	width, height int
+
+	gif        *os.File
+	gifBuf     *bufio.Writer
+	gifPixels  []byte
+	delayError float64
+
+	y4m     *os.File
+	y4mBuf  *bufio.Writer
+	y4mLine []byte
+
+	wav        *os.File
+	wavBuf     *bufio.Writer
+	wavSamples int
+}
+
+func NewRecorder(options RecorderOptions) (*Recorder, error) {
+	options.Scale = max(options.Scale, 1)
+	options.FrameSkip = max(options.FrameSkip, 0)
+
+	r := &Recorder{options: options}
+
+	switch strings.ToLower(filepath.Ext(options.Path)) {
+	case ".gif":
+		file, err := os.Create(options.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create recording: %v", err)
+		}
+		r.gif = file
+		r.gifBuf = bufio.NewWriter(file)
+	case ".y4m":
+		file, err := os.Create(options.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create recording: %v", err)
+		}
+		r.y4m = file
+		r.y4mBuf = bufio.NewWriter(file)
+	default:
+		return nil, fmt.Errorf("unsupported recording format %q: use .gif or .y4m", filepath.Ext(options.Path))
+	}
+
+	if options.Audio {
+		file, err := os.Create(r.AudioPath())
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to create audio recording: %v", err)
+		}
+		r.wav = file
+		r.wavBuf = bufio.NewWriter(file)
+		// The header is rewritten with the final sizes on Close.
+		writeWavHeader(r.wavBuf, 0)
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) Path() string {
+	return r.options.Path
+}
+
+func (r *Recorder) AudioPath() string {
+	return strings.TrimSuffix(r.options.Path, filepath.Ext(r.options.Path)) + ".wav"
+}
+
+// AddFrame records one presented frame. It must be called once per frame,
+// skipped frames are dropped here so that the audio stays in sync.
+func (r *Recorder) AddFrame(videoBuffer []bool, width, height int, isBuzzing bool) error {
+	if r.wav != nil {
+		if err := r.addAudio(isBuzzing); err != nil {
+			return err
+		}
+	}
+
+	isSkipped := r.frame%(r.options.FrameSkip+1) != 0
+	r.frame++
+	if isSkipped {
+		return nil
+	}
+
+	// Both formats need a fixed size, so frames from a different display
+	// mode than the first one are left out.
+	if r.width == 0 {
+		r.width, r.height = width, height
+	} else if r.width != width || r.height != height {
+		return nil
+	}
+
+	if r.gif != nil {
+		return r.addGifFrame(videoBuffer)
+	}
+
+	return r.addY4mFrame(videoBuffer)
+}
+
+// addGifFrame writes a frame to the GIF as it is captured, so that long
+// recordings are not held in memory. The header, with the two colours of
+// the palette as the global colour table, is written with the first frame.
+func (r *Recorder) addGifFrame(videoBuffer []bool) error {
+	scale := r.options.Scale
+	width, height := r.width*scale, r.height*scale
+
+	if r.gifPixels == nil {
+		r.gifBuf.WriteString("GIF89a")
+		binary.Write(r.gifBuf, binary.LittleEndian, [2]uint16{uint16(width), uint16(height)})
+		// A global colour table of 2 entries, background colour 0.
+		r.gifBuf.Write([]byte{0xF0, 0x00, 0x00})
+		for i := range 2 {
+			c := r.options.Palette.Color(uint8(i))
+			r.gifBuf.Write([]byte{c.R, c.G, c.B})
+		}
+		// Loop forever.
+		r.gifBuf.Write([]byte{0x21, 0xFF, 0x0B})
+		r.gifBuf.WriteString("NETSCAPE2.0")
+		r.gifBuf.Write([]byte{0x03, 0x01, 0x00, 0x00, 0x00})
+
+		r.gifPixels = make([]byte, width*height)
+	}
+
+	for y := range height {
+		for x := range width {
+			r.gifPixels[y*width+x] = 0
+			if videoBuffer[(y/scale)*r.width+x/scale] {
+				r.gifPixels[y*width+x] = 1
+			}
+		}
+	}
+
+	// GIF delays are in hundredths of a second, carry the rounding error
+	// over so the average speed matches the frame rate.
+	exact := float64(r.options.FrameSkip+1)*100/FRAME_RATE + r.delayError
+	delay := math.Round(exact)
+	r.delayError = exact - delay
+
+	// Graphic control extension with the delay, then the image descriptor.
+	r.gifBuf.Write([]byte{0x21, 0xF9, 0x04, 0x00})
+	binary.Write(r.gifBuf, binary.LittleEndian, uint16(delay))
+	r.gifBuf.Write([]byte{0x00, 0x00, 0x2C})
+	binary.Write(r.gifBuf, binary.LittleEndian, [4]uint16{0, 0, uint16(width), uint16(height)})
+	r.gifBuf.Write([]byte{0x00, GIF_LZW_MIN_CODE_SIZE})
+
+	blocks := &gifBlockWriter{w: r.gifBuf}
+	encoder := lzw.NewWriter(blocks, lzw.LSB, GIF_LZW_MIN_CODE_SIZE)
+	encoder.Write(r.gifPixels)
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode GIF: %v", err)
+	}
+	if err := blocks.Close(); err != nil {
+		return fmt.Errorf("failed to write recording: %v", err)
+	}
+
+	return nil
+}
+
+// gifBlockWriter splits image data into the sub-blocks of at most 255
+// bytes GIF stores it in.
+type gifBlockWriter struct {
+	w     *bufio.Writer
+	block [255]byte
+	n     int
+}
+
+func (b *gifBlockWriter) Write(p []byte) (int, error) {
+	for i, x := range p {
+		b.block[b.n] = x
+		b.n++
+		if b.n == len(b.block) {
+			if err := b.flush(); err != nil {
+				return i, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (b *gifBlockWriter) flush() error {
+	b.w.WriteByte(byte(b.n))
+	_, err := b.w.Write(b.block[:b.n])
+	b.n = 0
+	return err
+}
+
+// Close writes the last sub-block and the terminator.
+func (b *gifBlockWriter) Close() error {
+	if b.n > 0 {
+		if err := b.flush(); err != nil {
+			return err
+		}
+	}
+	return b.w.WriteByte(0x00)
+}
+
+func (r *Recorder) addY4mFrame(videoBuffer []bool) error {
+	scale := r.options.Scale
+	width, height := r.width*scale, r.height*scale
+
+	if r.y4mLine == nil {
+		header := fmt.Sprintf("YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444\n", width, height, FRAME_RATE, r.options.FrameSkip+1)
+		if _, err := r.y4mBuf.WriteString(header); err != nil {
+			return fmt.Errorf("failed to write recording: %v", err)
+		}
+		r.y4mLine = make([]byte, width)
+	}
+
+	var planes [2][3]uint8
+	for i := range planes {
+		c := r.options.Palette.Color(uint8(i))
+		planes[i][0], planes[i][1], planes[i][2] = color.RGBToYCbCr(c.R, c.G, c.B)
+	}
+
+	r.y4mBuf.WriteString("FRAME\n")
+	for plane := range 3 {
+		for y := range height {
+			for x := range width {
+				index := 0
+				if videoBuffer[(y/scale)*r.width+x/scale] {
+					index = 1
+				}
+				r.y4mLine[x] = planes[index][plane]
+			}
+			if _, err := r.y4mBuf.Write(r.y4mLine); err != nil {
+				return fmt.Errorf("failed to write recording: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAudio writes one frame worth of the buzzer as a square wave.
+func (r *Recorder) addAudio(isBuzzing bool) error {
+	const samplesPerFrame = AUDIO_SAMPLE_RATE / FRAME_RATE
+	const halfPeriod = AUDIO_SAMPLE_RATE / BUZZER_FREQUENCY / 2
+
+	var samples [samplesPerFrame]int16
+	if isBuzzing {
+		for i := range samples {
+			if ((r.wavSamples+i)/halfPeriod)%2 == 0 {
+				samples[i] = 8000
+			} else {
+				samples[i] = -8000
+			}
+		}
+	}
+	r.wavSamples += samplesPerFrame
+
+	if err := binary.Write(r.wavBuf, binary.LittleEndian, samples[:]); err != nil {
+		return fmt.Errorf("failed to write audio recording: %v", err)
+	}
+
+	return nil
+}
+
+// Close finishes the recording and flushes it to disk.
+func (r *Recorder) Close() error {
+	var errs []error
+
+	if r.gif != nil {
+		if r.gifPixels != nil {
+			r.gifBuf.WriteByte(0x3B)
+		}
+		if err := r.gifBuf.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write recording: %v", err))
+		}
+		r.gif.Close()
+	}
+
+	if r.y4m != nil {
+		if err := r.y4mBuf.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write recording: %v", err))
+		}
+		r.y4m.Close()
+	}
+
+	if r.wav != nil {
+		if err := r.finishWav(); err != nil {
+			errs = append(errs, err)
+		}
+		r.wav.Close()
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (r *Recorder) finishWav() error {
+	if err := r.wavBuf.Flush(); err != nil {
+		return fmt.Errorf("failed to write audio recording: %v", err)
+	}
+
+	if _, err := r.wav.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to finish audio recording: %v", err)
+	}
+
+	return writeWavHeader(r.wav, r.wavSamples*2)
+}
+
+// writeWavHeader writes a 16-bit mono PCM header for dataSize bytes of
+// samples.
+func writeWavHeader(w io.Writer, dataSize int) error {
+	header := struct {
+		Riff          [4]byte
+		RiffSize      uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		Riff:          [4]byte{'R', 'I', 'F', 'F'},
+		RiffSize:      uint32(36 + dataSize),
+		Wave:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1,
+		Channels:      1,
+		SampleRate:    AUDIO_SAMPLE_RATE,
+		ByteRate:      AUDIO_SAMPLE_RATE * 2,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      uint32(dataSize),
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("failed to write WAV header: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package emulator
+
+import (
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecorderStreamsGif(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.gif")
+	recorder, err := NewRecorder(RecorderOptions{Path: path, Scale: 2, FrameSkip: 1, Palette: DefaultPalette()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("recording not created before the first frame: %v", err)
+	}
+
+	const width, height = 4, 3
+	for frame := range 6 {
+		video := make([]bool, width*height)
+		video[frame%len(video)] = true
+		if err := recorder.AddFrame(video, width, height, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	recording, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("failed to decode the recording: %v", err)
+	}
+
+	if len(recording.Image) != 3 {
+		t.Fatalf("%d frames, expected 3 with every other frame skipped", len(recording.Image))
+	}
+	for i, img := range recording.Image {
+		if bounds := img.Bounds(); bounds.Dx() != width*2 || bounds.Dy() != height*2 {
+			t.Errorf("frame %d is %dx%d, expected %dx%d", i, bounds.Dx(), bounds.Dy(), width*2, height*2)
+		}
+
+		lit := i * 2
+		x, y := lit%width*2, lit/width*2
+		if img.ColorIndexAt(x+1, y+1) != 1 || img.ColorIndexAt(x+2, y) != 0 {
+			t.Errorf("frame %d does not show pixel %d lit alone", i, lit)
+		}
+	}
+
+	delay := 0
+	for _, d := range recording.Delay {
+		delay += d
+	}
+	if delay != 10 {
+		t.Errorf("frames last %d hundredths of a second, expected 10", delay)
+	}
+}
@@ -0,0 +1,58 @@
+package emulator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ScaleMode controls how the display is fitted into a window of arbitrary
+// size. Both modes keep the aspect ratio and letterbox the remaining space.
+type ScaleMode int
+
+const (
+	// ScaleInteger only uses whole multiples of the native resolution so
+	// every CHIP-8 pixel has the same size.
+	ScaleInteger ScaleMode = iota
+	// ScaleFit fills as much of the window as possible.
+	ScaleFit
+)
+
+var scaleModeNames = [...]string{
+	ScaleInteger: "integer",
+	ScaleFit:     "fit",
+}
+
+func (m ScaleMode) String() string {
+	if int(m) < len(scaleModeNames) {
+		return scaleModeNames[m]
+	}
+	return fmt.Sprintf("ScaleMode(%d)", int(m))
+}
+
+func (m ScaleMode) Next() ScaleMode {
+	return (m + 1) % ScaleMode(len(scaleModeNames))
+}
+
+func ParseScaleMode(name string) (ScaleMode, error) {
+	for i, modeName := range scaleModeNames {
+		if modeName == name {
+			return ScaleMode(i), nil
+		}
+	}
+	return ScaleInteger, fmt.Errorf("unknown scale mode %q (available: %s)", name, strings.Join(scaleModeNames[:], ", "))
+}
+
+// FitDisplay returns the scale and top-left offset that place a
+// width x height display centered in a screenWidth x screenHeight screen.
+func FitDisplay(screenWidth, screenHeight, width, height int, mode ScaleMode) (scale, offsetX, offsetY float64) {
+	scale = math.Min(float64(screenWidth)/float64(width), float64(screenHeight)/float64(height))
+	if mode == ScaleInteger && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+
+	offsetX = math.Floor((float64(screenWidth) - float64(width)*scale) / 2)
+	offsetY = math.Floor((float64(screenHeight) - float64(height)*scale) / 2)
+
+	return scale, offsetX, offsetY
+}
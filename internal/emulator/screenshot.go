@@ -0,0 +1,80 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ScreenshotInfo is embedded in screenshots as PNG text chunks.
+type ScreenshotInfo struct {
+	RomName string
+	RomHash string
+	Cycles  uint64
+}
+
+// EncodeScreenshot renders a width x height video buffer with palette,
+// scaling every CHIP-8 pixel to scale x scale, and encodes it as PNG.
+func EncodeScreenshot(videoBuffer []bool, width, height, scale int, palette Palette, info ScreenshotInfo) ([]byte, error) {
+	if len(videoBuffer) < width*height {
+		return nil, fmt.Errorf("video buffer too small for %dx%d: %d pixels", width, height, len(videoBuffer))
+	}
+	scale = max(scale, 1)
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, width*scale, height*scale),
+		color.Palette{palette.Color(0), palette.Color(1)},
+	)
+	for y := range height * scale {
+		for x := range width * scale {
+			if videoBuffer[(y/scale)*width+x/scale] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode screenshot: %v", err)
+	}
+
+	return insertPngText(buf.Bytes(), [][2]string{
+		{"Software", "G8Emu"},
+		{"ROM", info.RomName},
+		{"ROM-SHA1", info.RomHash},
+		{"Cycles", fmt.Sprint(info.Cycles)},
+	}), nil
+}
+
+// insertPngText adds tEXt chunks right after the IHDR chunk, which the
+// standard library encoder cannot write itself.
+func insertPngText(data []byte, entries [][2]string) []byte {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, length, type, data, crc
+
+	var chunks bytes.Buffer
+	for _, entry := range entries {
+		if entry[1] == "" {
+			continue
+		}
+
+		payload := append([]byte(entry[0]+"\x00"), entry[1]...)
+		binary.Write(&chunks, binary.BigEndian, uint32(len(payload)))
+
+		crc := crc32.NewIEEE()
+		crc.Write([]byte("tEXt"))
+		crc.Write(payload)
+
+		chunks.WriteString("tEXt")
+		chunks.Write(payload)
+		binary.Write(&chunks, binary.BigEndian, crc.Sum32())
+	}
+
+	out := make([]byte, 0, len(data)+chunks.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunks.Bytes()...)
+	return append(out, data[ihdrEnd:]...)
+}
@@ -0,0 +1,97 @@
+package emulator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ShaderOptions are the uniforms of the CRT post-processing shader. All
+// effects range from 0 (off) to 1, except Aberration which is the red/blue
+// split in screen pixels.
+type ShaderOptions struct {
+	Enabled    bool
+	Curvature  float64
+	Scanlines  float64
+	Bloom      float64
+	Vignette   float64
+	Aberration float64
+}
+
+var shaderParams = []string{"curvature", "scanlines", "bloom", "vignette", "aberration"}
+
+func DefaultShaderOptions() ShaderOptions {
+	return ShaderOptions{
+		Enabled:    false,
+		Curvature:  0.3,
+		Scanlines:  0.35,
+		Bloom:      0.4,
+		Vignette:   0.5,
+		Aberration: 1,
+	}
+}
+
+// ParseShaderOptions parses "off", "on" or a comma separated list of
+// parameters such as "curvature=0.2,scanlines=0.5". Parameters that are
+// not listed keep their default value.
+func ParseShaderOptions(spec string) (ShaderOptions, error) {
+	options := DefaultShaderOptions()
+
+	switch spec {
+	case "", "off":
+		return options, nil
+	case "on":
+		options.Enabled = true
+		return options, nil
+	}
+
+	options.Enabled = true
+	for _, part := range strings.Split(spec, ",") {
+		name, rawValue, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return options, fmt.Errorf("invalid shader parameter %q: expected name=value", part)
+		}
+
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return options, fmt.Errorf("invalid value for shader parameter %q: %v", name, err)
+		}
+
+		param := options.Param(name)
+		if param == nil {
+			return options, fmt.Errorf("unknown shader parameter %q (available: %s)", name, strings.Join(shaderParams, ", "))
+		}
+		*param = value
+	}
+
+	return options, nil
+}
+
+// Param returns a pointer to the named parameter so it can be tuned at
+// runtime, or nil when the name is unknown.
+func (o *ShaderOptions) Param(name string) *float64 {
+	switch name {
+	case "curvature":
+		return &o.Curvature
+	case "scanlines":
+		return &o.Scanlines
+	case "bloom":
+		return &o.Bloom
+	case "vignette":
+		return &o.Vignette
+	case "aberration":
+		return &o.Aberration
+	}
+	return nil
+}
+
+// Uniforms returns the options as Kage shader uniforms.
+func (o ShaderOptions) Uniforms() map[string]any {
+	return map[string]any{
+		"Curvature":  float32(o.Curvature),
+		"Scanlines":  float32(o.Scanlines),
+		"Bloom":      float32(o.Bloom),
+		"Vignette":   float32(o.Vignette),
+		"Aberration": float32(o.Aberration),
+	}
+}
@@ -0,0 +1,172 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+// STATE_EXTENSION is the extension of save state files.
+const STATE_EXTENSION = ".state"
+
+// saveState is the content of a save state file, gob encoded.
+type saveState struct {
+	RomName string
+	RomHash string
+	Chip8   core.Snapshot
+}
+
+// SetStateDir sets where the save state hotkeys write and read states.
+func (e *Engine) SetStateDir(dir string) {
+	e.stateDir = dir
+}
+
+// SaveState writes the machine state to path.
+func (e *Engine) SaveState(path string) error {
+	state := saveState{
+		RomName: e.romName,
+		RomHash: e.romHash,
+		Chip8:   e.chip8.Snapshot(),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return fmt.Errorf("failed to encode save state: %v", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write save state: %v", err)
+	}
+
+	return nil
+}
+
+// LoadState restores a state saved while running the loaded ROM.
+func (e *Engine) LoadState(path string) error {
+	state, err := readState(path)
+	if err != nil {
+		return err
+	}
+
+	if state.RomHash != e.romHash {
+		return fmt.Errorf("%s was saved with another ROM (%s)", path, state.RomName)
+	}
+	if err := e.checkStateSettings(path, state); err != nil {
+		return err
+	}
+
+	if err := e.restore(state.Chip8); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", path, err)
+	}
+	return nil
+}
+
+func readState(path string) (*saveState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read save state: %v", err)
+	}
+
+	state := &saveState{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(state); err != nil {
+		return nil, fmt.Errorf("failed to decode save state %s: %v", path, err)
+	}
+
+	return state, nil
+}
+
+// checkStateSettings refuses states saved on another machine, whose
+// memory and display are laid out differently, and warns about states
+// saved with other quirks, which only change how the program goes on.
+func (e *Engine) checkStateSettings(path string, state *saveState) error {
+	if machine := e.chip8.Machine(); state.Chip8.Machine != machine {
+		return fmt.Errorf("%s was saved on %s, not %s", path, state.Chip8.Machine, machine)
+	}
+
+	if quirks := e.chip8.Quirks(); state.Chip8.Quirks != quirks {
+		message := fmt.Sprintf("%s was saved with quirks %q, running with %q", path, state.Chip8.Quirks, quirks)
+		log.Print(message)
+		e.display.Notify(message)
+	}
+
+	return nil
+}
+
+func (e *Engine) restore(snapshot core.Snapshot) error {
+	if err := e.chip8.Restore(snapshot); err != nil {
+		return err
+	}
+
+	e.lastUpdate = e.now()
+	e.lastTimer = e.now()
+	e.timeAccumulator = 0
+
+	e.latchedVideo = e.chip8.Video
+	e.hasDrawn = false
+	e.framesSinceLatch = 0
+
+	e.restartTiming()
+	return nil
+}
+
+// latestState finds the newest save state of the loaded ROM in the state
+// directory. States are named like captures, so names sort by time.
+func (e *Engine) latestState() (string, error) {
+	prefix := e.captureName() + "-"
+
+	matches, err := filepath.Glob(filepath.Join(e.stateDir, "*"+STATE_EXTENSION))
+	if err != nil {
+		return "", err
+	}
+
+	var paths []string
+	for _, path := range matches {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, prefix) && len(name) == len(prefix)+len(CAPTURE_TIME_LAYOUT)+len(STATE_EXTENSION) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no save state for %s", e.romName)
+	}
+
+	sort.Strings(paths)
+	return paths[len(paths)-1], nil
+}
+
+func (e *Engine) updateStateHotkeys() {
+	if e.input.Pressed(HotkeySaveState) {
+		if err := os.MkdirAll(e.stateDir, 0o755); err != nil {
+			log.Printf("failed to create state directory: %v", err)
+		} else {
+			path := e.capturePath(e.stateDir, STATE_EXTENSION)
+			if err := e.SaveState(path); err != nil {
+				log.Printf("%v", err)
+			} else {
+				log.Printf("saved state %s", path)
+				e.display.Notify("state saved")
+			}
+		}
+	}
+
+	if e.input.Pressed(HotkeyLoadState) {
+		path, err := e.latestState()
+		if err == nil {
+			err = e.LoadState(path)
+		}
+		if err != nil {
+			log.Printf("failed to load state: %v", err)
+			e.display.Notify(err.Error())
+		} else {
+			log.Printf("loaded state %s", path)
+			e.display.Notify("state loaded")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+func TestLoadStateChecksSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		saved     core.Options
+		isError   bool
+		isWarning bool
+	}{
+		{name: "same settings"},
+		{name: "other machine", saved: core.Options{Machine: core.MachineChip48}, isError: true},
+		{name: "other quirks", saved: core.Options{Quirks: core.Quirks{Wrap: true}}, isWarning: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test"+STATE_EXTENSION)
+
+			saver := NewEngine(core.NewChip8WithOptions(test.saved), &MemoryDisplay{}, &MemoryInput{}, &MemoryAudio{}, TEST_FREQUENCY)
+			if err := saver.LoadRom("test.ch8", testProgram); err != nil {
+				t.Fatal(err)
+			}
+			if err := saver.SaveState(path); err != nil {
+				t.Fatal(err)
+			}
+
+			te := newTestEngine(t)
+			te.advance(t, 20*time.Millisecond)
+
+			err := te.LoadState(path)
+			if test.isError != (err != nil) {
+				t.Fatalf("error %v, expected one: %t", err, test.isError)
+			}
+			if !test.isError && te.chip8.Cycles() != 0 {
+				t.Errorf("%d cycles after loading the state, expected 0", te.chip8.Cycles())
+			}
+
+			isWarned := len(te.display.Messages) > 0 && strings.Contains(te.display.Messages[len(te.display.Messages)-1], "quirks")
+			if isWarned != test.isWarning {
+				t.Errorf("messages %q, expected a warning: %t", te.display.Messages, test.isWarning)
+			}
+		})
+	}
+}
+
+func TestLoadStateRefusesCorruptStates(t *testing.T) {
+	te := newTestEngine(t)
+	te.advance(t, 20*time.Millisecond)
+	cycles := te.chip8.Cycles()
+
+	state := saveState{RomName: te.romName, RomHash: te.romHash, Chip8: te.chip8.Snapshot()}
+	state.Chip8.SP = 17
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "corrupt"+STATE_EXTENSION)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := te.LoadState(path); err == nil {
+		t.Fatal("loaded a state with the stack pointer past the stack")
+	}
+	if te.chip8.Cycles() != cycles {
+		t.Errorf("%d cycles after a refused state, expected %d", te.chip8.Cycles(), cycles)
+	}
+}
@@ -0,0 +1,144 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+// MAX_VIP_FRAMES is how many frames VIP timing catches up on in a single
+// update, after which it drops behind instead of running ever longer.
+const MAX_VIP_FRAMES = 4
+
+// Timing selects how long instructions take.
+type Timing int
+
+const (
+	// TimingFixed runs every instruction in the same time, set by the CPU
+	// frequency.
+	TimingFixed Timing = iota
+	// TimingVip runs instructions for as many machine cycles as on the
+	// COSMAC VIP, in the part of each frame left by its display and
+	// interrupt, and has DXYN wait for the next frame before drawing.
+	TimingVip
+)
+
+var timingNames = [...]string{
+	TimingFixed: "fixed",
+	TimingVip:   "vip",
+}
+
+func (t Timing) String() string {
+	if int(t) < len(timingNames) {
+		return timingNames[t]
+	}
+	return fmt.Sprintf("Timing(%d)", int(t))
+}
+
+func (t Timing) Next() Timing {
+	return (t + 1) % Timing(len(timingNames))
+}
+
+func ParseTiming(name string) (Timing, error) {
+	for i, timingName := range timingNames {
+		if timingName == name {
+			return Timing(i), nil
+		}
+	}
+	return TimingFixed, fmt.Errorf("unknown timing %q (available: %s)", name, strings.Join(timingNames[:], ", "))
+}
+
+// SetTiming switches between fixed and VIP timing. The CPU frequency only
+// applies to fixed timing.
+func (e *Engine) SetTiming(timing Timing) {
+	e.timing = timing
+	e.restartTiming()
+}
+
+func (e *Engine) Timing() Timing {
+	return e.timing
+}
+
+// runVip runs the frames elapsed since the last one. Every frame starts
+// with the interrupt, which counts the timers down, and instructions run
+// in the machine cycles the display leaves.
+//
+// vipCycles carries over between frames: an instruction overrunning a frame
+// leaves a debt taken from the next one. Frames that are not run, while
+// paused or when dropping behind, lose the cycles left in them but keep the
+// debt.
+func (e *Engine) runVip(currentTime time.Time) {
+	frame := time.Second / 60
+
+	if e.chip8.IsPaused() || currentTime.Sub(e.lastTimer) > MAX_VIP_FRAMES*frame {
+		e.lastTimer = currentTime
+		e.vipCycles = min(e.vipCycles, 0)
+		return
+	}
+
+	for currentTime.Sub(e.lastTimer) >= frame && !e.chip8.IsPaused() {
+		e.lastTimer = e.lastTimer.Add(frame)
+
+		if e.chip8.DelayTimer > 0 {
+			e.chip8.DelayTimer--
+		}
+		if e.chip8.SoundTimer > 0 {
+			e.chip8.SoundTimer--
+		}
+
+		e.vipCycles += core.VIP_CYCLES_PER_FRAME - core.VIP_INTERRUPT_CYCLES
+		e.runVipFrame()
+	}
+}
+
+func (e *Engine) runVipFrame() {
+	isDisplayWait := e.settings.Filter.Filter == FilterDisplayWait
+
+	for e.vipCycles > 0 && !e.chip8.IsPaused() {
+		pc := e.chip8.PC()
+		if e.breakpoints[pc] {
+			e.chip8.Pause()
+			e.display.Notify(fmt.Sprintf("breakpoint at %03X", pc))
+			return
+		}
+
+		opcode := e.chip8.PeekOpcode(pc & 0xFFF)
+		registers := e.chip8.Registers()
+		x := registers[(opcode&0x0F00)>>8]
+
+		// Drawing waits for the interrupt, so the rest of the frame is
+		// lost and the sprite is drawn at the start of the next. Any debt
+		// was already paid from this frame, as the loop only runs with
+		// cycles left.
+		if opcode&0xF000 == 0xD000 && !e.isWaitingVblank {
+			e.isWaitingVblank = true
+			e.vipCycles = 0
+			return
+		}
+		e.isWaitingVblank = false
+
+		e.chip8.Cycle()
+		e.vipCycles -= core.VipCycles(opcode, isSkipped(opcode, pc, e.chip8.PC()), x)
+
+		if isDisplayWait {
+			e.latchVideo()
+		}
+	}
+}
+
+// isSkipped reports whether the instruction opcode at pc skipped the next
+// one, given the pc after it. Jumps landing on pc+4 are not skips.
+func isSkipped(opcode, pc, next uint16) bool {
+	switch opcode >> 12 {
+	case 0x3, 0x4, 0x5, 0x9, 0xE:
+		return next&0xFFF == (pc+4)&0xFFF
+	}
+	return false
+}
+
+func (e *Engine) restartTiming() {
+	e.vipCycles = 0
+	e.isWaitingVblank = false
+}
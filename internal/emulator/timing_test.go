@@ -0,0 +1,99 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsSkipped(t *testing.T) {
+	tests := []struct {
+		opcode, pc, next uint16
+		expected         bool
+	}{
+		{0x3000, 0x200, 0x204, true},
+		{0x3000, 0x200, 0x202, false},
+		{0xE09E, 0xFFE, 0x002, true},
+		{0x1204, 0x200, 0x204, false},
+		{0xB204, 0x200, 0x204, false},
+		{0x6000, 0x200, 0x202, false},
+	}
+
+	for _, test := range tests {
+		if isSkipped(test.opcode, test.pc, test.next) != test.expected {
+			t.Errorf("%04X at %03X then %03X: skipped %t, expected %t", test.opcode, test.pc, test.next, !test.expected, test.expected)
+		}
+	}
+}
+
+// newVipEngine runs program with VIP timing.
+func newVipEngine(t *testing.T, program []byte) *testEngine {
+	t.Helper()
+
+	te := newTestEngine(t)
+	if err := te.LoadRom("vip.ch8", program); err != nil {
+		t.Fatalf("failed to load ROM: %v", err)
+	}
+	te.SetTiming(TimingVip)
+	return te
+}
+
+// vipLoop adds to V0 in a loop.
+var vipLoop = []byte{
+	0x70, 0x01, // ADD V0, 1
+	0x12, 0x00, // JP 200
+}
+
+func TestVipDrawWaitsForNextFrame(t *testing.T) {
+	te := newVipEngine(t, []byte{
+		0xA2, 0x00, // LD I, 200
+		0xD0, 0x01, // DRW V0, V0, 1
+		0x12, 0x04, // JP 204
+	})
+
+	te.advance(t, time.Second/60)
+	if te.chip8.Cycles() != 1 || te.chip8.Video[0] {
+		t.Fatalf("%d cycles in the first frame, expected DXYN to wait after 1", te.chip8.Cycles())
+	}
+
+	te.advance(t, time.Second/60)
+	if !te.chip8.Video[0] || !te.chip8.Video[2] {
+		t.Errorf("sprite not drawn at the start of the second frame")
+	}
+}
+
+func TestVipBreakpointDropsRestOfFrame(t *testing.T) {
+	reference := newVipEngine(t, vipLoop)
+	reference.advance(t, time.Second/60)
+	perFrame := reference.chip8.Cycles()
+
+	te := newVipEngine(t, vipLoop)
+	te.ToggleBreakpoint(0x202)
+	te.advance(t, time.Second/60)
+	if !te.IsPaused() || te.chip8.Cycles() != 1 {
+		t.Fatalf("paused %t after %d cycles, expected a pause after 1", te.IsPaused(), te.chip8.Cycles())
+	}
+
+	te.ToggleBreakpoint(0x202)
+	te.Continue()
+	te.advance(t, time.Second/60)
+
+	// The loop alternates between two instructions, so a frame starting
+	// on the other one may run one more.
+	if cycles := te.chip8.Cycles() - 1; cycles > perFrame+1 {
+		t.Errorf("%d cycles in the frame after resuming, expected at most %d", cycles, perFrame+1)
+	}
+}
+
+func TestVipBreakpointStopsTimers(t *testing.T) {
+	te := newVipEngine(t, []byte{
+		0x60, 0xFF, // LD V0, FF
+		0xF0, 0x15, // LD DT, V0
+		0x12, 0x04, // JP 204
+	})
+	te.ToggleBreakpoint(0x204)
+
+	te.advance(t, 3*time.Second/60)
+	if !te.IsPaused() || te.chip8.DelayTimer != 0xFF {
+		t.Errorf("paused %t with delay timer %d, expected the frames after the breakpoint not to run", te.IsPaused(), te.chip8.DelayTimer)
+	}
+}
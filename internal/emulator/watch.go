@@ -0,0 +1,134 @@
+package emulator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// WATCH_INTERVAL is how often a watched ROM file is checked for changes.
+const WATCH_INTERVAL = 500 * time.Millisecond
+
+type WatchOptions struct {
+	// KeepBreakpoints keeps the debugger breakpoints across reloads,
+	// which are cleared otherwise.
+	KeepBreakpoints bool
+	// StatePath is a save state restored after every reload, with the new
+	// program copied over the saved memory, to resume at the same point.
+	StatePath string
+	// PatchPath is the patch given when the ROM was loaded, applied again
+	// on every reload. When empty, a patch next to the ROM is looked for.
+	PatchPath string
+}
+
+// watcher polls a ROM file for changes in size or modification time.
+type watcher struct {
+	path      string
+	options   WatchOptions
+	size      int64
+	modTime   time.Time
+	lastCheck time.Time
+
+	// entry is the ROM chosen in a ZIP archive, reloaded from it.
+	entry string
+}
+
+// Watch reloads the ROM at path whenever the file changes on disk. The
+// ROM should already be loaded, so that the same one is picked from an
+// archive; the save state in options is restored right away.
+func (e *Engine) Watch(path string, options WatchOptions) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to watch ROM: %v", err)
+	}
+
+	e.watcher = &watcher{
+		path:      path,
+		options:   options,
+		entry:     e.romName,
+		size:      info.Size(),
+		modTime:   info.ModTime(),
+		lastCheck: e.now(),
+	}
+
+	if options.StatePath != "" {
+		return e.restoreWatchState(e.chip8.Rom())
+	}
+	return nil
+}
+
+func (e *Engine) StopWatching() {
+	e.watcher = nil
+}
+
+func (e *Engine) checkWatch() {
+	w := e.watcher
+	if w == nil || e.now().Sub(w.lastCheck) < WATCH_INTERVAL {
+		return
+	}
+	w.lastCheck = e.now()
+
+	info, err := os.Stat(w.path)
+	if err != nil || (info.Size() == w.size && info.ModTime().Equal(w.modTime)) {
+		return
+	}
+
+	// A file being written may not decode yet; it is retried on the next
+	// change.
+	w.size = info.Size()
+	w.modTime = info.ModTime()
+
+	if err := e.reloadWatched(); err != nil {
+		log.Printf("failed to reload ROM: %v", err)
+		e.display.Notify(err.Error())
+		return
+	}
+	log.Printf("reloaded %s", w.path)
+	e.display.Notify("reloaded " + e.romName)
+}
+
+func (e *Engine) reloadWatched() error {
+	w := e.watcher
+
+	image, _, err := rom.Load(w.path, w.options.PatchPath, rom.NamePicker(w.entry))
+	if err != nil {
+		return err
+	}
+
+	if err := e.LoadRom(image.Name, image.Data); err != nil {
+		return err
+	}
+	if !w.options.KeepBreakpoints {
+		e.breakpoints = make(map[uint16]bool)
+	}
+
+	if w.options.StatePath != "" {
+		return e.restoreWatchState(image.Data)
+	}
+	return nil
+}
+
+// restoreWatchState restores the watch save state, whatever ROM it was
+// saved with, and loads program over it.
+func (e *Engine) restoreWatchState(program []byte) error {
+	state, err := readState(e.watcher.options.StatePath)
+	if err != nil {
+		return err
+	}
+	if err := e.checkStateSettings(e.watcher.options.StatePath, state); err != nil {
+		return err
+	}
+
+	program = append([]byte(nil), program...)
+	if err := e.restore(state.Chip8); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", e.watcher.options.StatePath, err)
+	}
+	if err := e.chip8.LoadRomBytes(program); err != nil {
+		return err
+	}
+
+	return nil
+}
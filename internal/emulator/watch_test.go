@@ -0,0 +1,70 @@
+package emulator
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// writeZip writes an archive holding files, in order.
+func writeZip(t *testing.T, path string, files [][2]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, f := range files {
+		entry, err := writer.Create(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(f[1]))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchReloadsSameEntryWithPatch(t *testing.T) {
+	dir := t.TempDir()
+	romPath := filepath.Join(dir, "games.zip")
+	patchPath := filepath.Join(dir, "fix.ips")
+
+	writeZip(t, romPath, [][2]string{{"a.ch8", "\x12\x00"}, {"b.ch8", "\x12\x02\x00\x00"}})
+	// The patch writes EE at the start of the ROM.
+	if err := os.WriteFile(patchPath, []byte(rom.IPS_MAGIC+"\x00\x00\x00\x00\x01\xEE"+rom.IPS_EOF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	te := newTestEngine(t)
+	image, _, err := rom.Load(romPath, patchPath, rom.NamePicker("b.ch8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := te.LoadRom(image.Name, image.Data); err != nil {
+		t.Fatal(err)
+	}
+	if err := te.Watch(romPath, WatchOptions{PatchPath: patchPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeZip(t, romPath, [][2]string{{"a.ch8", "\x12\x00"}, {"b.ch8", "\x12\x02\x00\x00\x00\x42"}})
+	te.advance(t, WATCH_INTERVAL)
+
+	if te.RomName() != "b.ch8" {
+		t.Fatalf("reloaded %s, expected b.ch8", te.RomName())
+	}
+	if value := te.chip8.Peek(0x200); value != 0xEE {
+		t.Errorf("%02X at 200, expected the patch applied again", value)
+	}
+	if value := te.chip8.Peek(0x205); value != 0x42 {
+		t.Errorf("%02X at 205, expected the new ROM", value)
+	}
+}
@@ -0,0 +1,48 @@
+package library
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeepDropped copies a ROM dropped on the window into dir and returns the
+// path of the copy. A file of the same name is never overwritten: when its
+// contents differ, the copy gets the start of the ROM's hash appended to
+// its name, and identical contents are reused.
+func KeepDropped(dir, name string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	name = filepath.Base(name)
+	sum := sha1.Sum(data)
+	ext := filepath.Ext(name)
+	hashed := strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:4]) + ext
+
+	for _, candidate := range []string{name, hashed} {
+		path := filepath.Join(dir, candidate)
+
+		existing, err := os.ReadFile(path)
+		if err == nil {
+			if bytes.Equal(existing, data) {
+				return path, nil
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to copy dropped ROM: %v", err)
+		}
+		return path, nil
+	}
+
+	return "", fmt.Errorf("%s and %s already hold other ROMs", name, hashed)
+}
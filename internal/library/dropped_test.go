@@ -0,0 +1,38 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeepDropped(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dropped")
+
+	first, err := KeepDropped(dir, "/elsewhere/game.ch8", []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != filepath.Join(dir, "game.ch8") {
+		t.Errorf("first copy at %s, expected game.ch8 in %s", first, dir)
+	}
+
+	again, err := KeepDropped(dir, "game.ch8", []byte("first"))
+	if err != nil || again != first {
+		t.Errorf("same ROM dropped again kept at %s (%v), expected %s", again, err, first)
+	}
+
+	second, err := KeepDropped(dir, "game.ch8", []byte("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Fatalf("another ROM with the same name kept at %s, over the first", second)
+	}
+
+	for path, expected := range map[string]string{first: "first", second: "second"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != expected {
+			t.Errorf("%s holds %q (%v), expected %q", path, data, err, expected)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// History records when each ROM was last played, keyed by absolute path.
+type History struct {
+	Played map[string]time.Time
+
+	path string
+}
+
+// LoadHistory reads the history file at path. A missing file gives an
+// empty history; an empty path keeps it in memory only.
+func LoadHistory(path string) (*History, error) {
+	h := &History{Played: map[string]time.Time{}, path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("failed to read history: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &h.Played); err != nil {
+		return h, fmt.Errorf("failed to parse history %s: %v", path, err)
+	}
+
+	return h, nil
+}
+
+// Touch marks a ROM as played at the given time and saves the history.
+func (h *History) Touch(romPath string, now time.Time) error {
+	if abs, err := filepath.Abs(romPath); err == nil {
+		romPath = abs
+	}
+	h.Played[romPath] = now
+
+	return h.Save()
+}
+
+func (h *History) LastPlayed(romPath string) (time.Time, bool) {
+	if abs, err := filepath.Abs(romPath); err == nil {
+		romPath = abs
+	}
+	played, ok := h.Played[romPath]
+	return played, ok
+}
+
+// Recent returns up to n ROM paths, most recently played first.
+func (h *History) Recent(n int) []string {
+	paths := make([]string, 0, len(h.Played))
+	for path := range h.Played {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return h.Played[paths[i]].After(h.Played[paths[j]])
+	})
+
+	if len(paths) > n {
+		paths = paths[:n]
+	}
+	return paths
+}
+
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(h.Played, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	if err := os.WriteFile(h.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package library finds the ROMs on disk for the launcher, with their
+// titles, when they were last played and their latest screenshot.
+package library
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/romdb"
+)
+
+// Extensions are the ROM files picked up by Scan.
+var Extensions = []string{".ch8", ".sc8", ".xo8"}
+
+// THUMBNAIL_SUFFIX ends the names of native resolution screenshots, which
+// serve as thumbnails.
+const THUMBNAIL_SUFFIX = "-native.png"
+
+type Entry struct {
+	Path    string
+	Title   string
+	Authors []string
+	Release string
+	Hash    string
+	// LastPlayed is zero for ROMs never played.
+	LastPlayed time.Time
+	// Thumbnail is the path of the ROM's latest screenshot, empty if there
+	// is none.
+	Thumbnail string
+}
+
+// Scan walks dirs for ROM files and describes them, most recently played
+// first and then by title. Titles come from db, falling back to the file
+// name. Files and directories that cannot be read are skipped, and
+// reported together after scanning the rest.
+func Scan(dirs []string, db *romdb.Database, history *History, screenshotDir string) ([]Entry, error) {
+	thumbnails := findThumbnails(screenshotDir)
+
+	var entries []Entry
+	var errs []error
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir {
+					return err
+				}
+				errs = append(errs, fmt.Errorf("failed to scan %s: %v", path, err))
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !isRom(path) {
+				return nil
+			}
+
+			entry, err := describe(path, db, history)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to read %s: %v", path, err))
+				return nil
+			}
+			entry.Thumbnail = thumbnails[strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))]
+			entries = append(entries, entry)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to scan %s: %v", dir, err))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.LastPlayed.Equal(b.LastPlayed) {
+			return a.LastPlayed.After(b.LastPlayed)
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+
+	return entries, errors.Join(errs...)
+}
+
+func isRom(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, romExt := range Extensions {
+		if ext == romExt {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(path string, db *romdb.Database, history *History) (Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	sum := sha1.Sum(data)
+
+	entry := Entry{
+		Path:  path,
+		Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Hash:  hex.EncodeToString(sum[:]),
+	}
+
+	if program, ok := db.Lookup(entry.Hash); ok {
+		entry.Title = program.Title
+		entry.Authors = program.Authors
+		entry.Release = program.Release
+	}
+
+	entry.LastPlayed, _ = history.LastPlayed(path)
+
+	return entry, nil
+}
+
+// findThumbnails maps ROM names to their latest native resolution
+// screenshot, named "<rom>-<yyyymmdd>-<hhmmss>-native.png".
+func findThumbnails(screenshotDir string) map[string]string {
+	thumbnails := map[string]string{}
+
+	files, err := os.ReadDir(screenshotDir)
+	if err != nil {
+		return thumbnails
+	}
+
+	// Timestamps sort in order, so the last match is the latest.
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, THUMBNAIL_SUFFIX) {
+			continue
+		}
+		stem := strings.TrimSuffix(name, THUMBNAIL_SUFFIX)
+		if len(stem) < len("-20060102-150405")+1 {
+			continue
+		}
+		rom := stem[:len(stem)-len("-20060102-150405")]
+		thumbnails[rom] = filepath.Join(screenshotDir, name)
+	}
+
+	return thumbnails
+}
+
+// FormatLastPlayed describes a play time relative to now, e.g. "3 hours
+// ago".
+func FormatLastPlayed(played, now time.Time) string {
+	if played.IsZero() {
+		return "never played"
+	}
+
+	elapsed := now.Sub(played)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return plural(int(elapsed/time.Minute), "minute") + " ago"
+	case elapsed < 24*time.Hour:
+		return plural(int(elapsed/time.Hour), "hour") + " ago"
+	case elapsed < 30*24*time.Hour:
+		return plural(int(elapsed/(24*time.Hour)), "day") + " ago"
+	}
+	return played.Format("2006-01-02")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
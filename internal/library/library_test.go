@@ -0,0 +1,48 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mochaeng/G8Emu/internal/romdb"
+)
+
+func TestScanSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha.ch8", "notes.txt", filepath.Join("more", "beta.ch8")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A dangling link is listed but cannot be read, and sorts before the
+	// other ROMs.
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "aardvark.ch8")); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+
+	history, _ := LoadHistory("")
+	entries, err := Scan([]string{dir, filepath.Join(dir, "absent")}, &romdb.Database{}, history, t.TempDir())
+
+	var titles []string
+	for _, entry := range entries {
+		titles = append(titles, entry.Title)
+	}
+	if strings.Join(titles, ",") != "alpha,beta" {
+		t.Errorf("found %q, expected alpha and beta", titles)
+	}
+
+	if err == nil {
+		t.Fatal("no error for the unreadable ROM and the absent directory")
+	}
+	for _, expected := range []string{"aardvark.ch8", "absent"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error %q does not report %s", err, expected)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package rom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const BPS_MAGIC = "BPS1"
+
+// BPS_MAX_NUMBER_BYTES is the longest number read from a BPS patch. Nine
+// bytes of seven bits cover every positive int.
+const BPS_MAX_NUMBER_BYTES = 9
+
+// BPS actions, stored in the low two bits of each action header.
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// applyBPS applies a BPS patch, checking the CRC32 of the source, of the
+// result and of the patch itself.
+func applyBPS(source, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(BPS_MAGIC)) {
+		return nil, fmt.Errorf("not a BPS patch")
+	}
+	if len(patch) < len(BPS_MAGIC)+12 {
+		return nil, fmt.Errorf("truncated BPS patch")
+	}
+
+	footer := patch[len(patch)-12:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:4])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:8])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:12])
+
+	if crc := crc32.ChecksumIEEE(patch[:len(patch)-4]); crc != patchCRC {
+		return nil, fmt.Errorf("BPS patch is corrupted: checksum %08X, expected %08X", crc, patchCRC)
+	}
+	if crc := crc32.ChecksumIEEE(source); crc != sourceCRC {
+		return nil, fmt.Errorf("BPS patch is for a different ROM: checksum %08X, expected %08X", crc, sourceCRC)
+	}
+
+	actions := patch[:len(patch)-12]
+	pos := len(BPS_MAGIC)
+	var decodeErr error
+
+	// decode reads a number. Every addition stays below 2^63, so a sum
+	// past the largest int wraps negative and is caught.
+	decode := func() int {
+		start := pos
+		value, shift := 0, 1
+		for range BPS_MAX_NUMBER_BYTES {
+			if pos >= len(actions) {
+				decodeErr = fmt.Errorf("truncated BPS patch at offset %d", pos)
+				return 0
+			}
+			x := actions[pos]
+			pos++
+			value += int(x&0x7F) * shift
+			if value < 0 {
+				break
+			}
+			if x&0x80 != 0 {
+				return value
+			}
+			shift <<= 7
+			value += shift
+			if value < 0 {
+				break
+			}
+		}
+		decodeErr = fmt.Errorf("BPS patch has a number too large at offset %d", start)
+		return 0
+	}
+
+	sourceSize := decode()
+	targetSize := decode()
+	metadataSize := decode()
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if sourceSize != len(source) {
+		return nil, fmt.Errorf("BPS patch expects a %d byte ROM, got %d bytes", sourceSize, len(source))
+	}
+	if targetSize > MAX_PATCHED_SIZE {
+		return nil, fmt.Errorf("BPS patch makes a %d byte ROM, larger than the %d bytes of memory", targetSize, MAX_PATCHED_SIZE)
+	}
+	if metadataSize > len(actions)-pos {
+		return nil, fmt.Errorf("truncated BPS patch: %d bytes of metadata at offset %d", metadataSize, pos)
+	}
+	pos += metadataSize
+
+	target := make([]byte, targetSize)
+	output, sourceOffset, targetOffset := 0, 0, 0
+
+	for pos < len(actions) {
+		header := decode()
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		length := header>>2 + 1
+		if length > targetSize-output {
+			return nil, fmt.Errorf("BPS patch writes past the end of the ROM")
+		}
+
+		switch header & 3 {
+		case bpsSourceRead:
+			if length > len(source)-output {
+				return nil, fmt.Errorf("BPS patch reads past the end of the ROM")
+			}
+			copy(target[output:], source[output:output+length])
+
+		case bpsTargetRead:
+			if length > len(actions)-pos {
+				return nil, fmt.Errorf("truncated BPS patch at offset %d", pos)
+			}
+			copy(target[output:], actions[pos:pos+length])
+			pos += length
+
+		case bpsSourceCopy, bpsTargetCopy:
+			delta := decode()
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			offset := delta >> 1
+			if delta&1 != 0 {
+				offset = -offset
+			}
+
+			if header&3 == bpsSourceCopy {
+				sourceOffset += offset
+				if sourceOffset < 0 || length > len(source)-sourceOffset {
+					return nil, fmt.Errorf("BPS patch reads past the end of the ROM")
+				}
+				copy(target[output:], source[sourceOffset:sourceOffset+length])
+				sourceOffset += length
+			} else {
+				targetOffset += offset
+				if targetOffset < 0 || targetOffset >= output {
+					return nil, fmt.Errorf("BPS patch copies from outside the written ROM")
+				}
+				// The ranges may overlap to repeat a pattern, so copy
+				// byte by byte.
+				for i := range length {
+					target[output+i] = target[targetOffset+i]
+				}
+				targetOffset += length
+			}
+		}
+
+		output += length
+	}
+
+	if crc := crc32.ChecksumIEEE(target); crc != targetCRC {
+		return nil, fmt.Errorf("patched ROM checksum %08X does not match expected %08X", crc, targetCRC)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,179 @@
+package rom
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RomExtensions are the file names picked out of archives.
+var RomExtensions = []string{".ch8", ".c8", ".sc8", ".xo8", ".8o", ".gif", ".hex", ".txt"}
+
+// Image is a decoded ROM along with the settings embedded in its file,
+// if any.
+type Image struct {
+	// Name is the file name of the ROM, inside the archive for ZIP files.
+	Name string
+	Data []byte
+	// TickRate is the number of instructions per 60Hz frame, 0 when the
+	// file does not say.
+	TickRate int
+	// Colors are hex colours for the background, the foreground and the
+	// two XO-CHIP plane colours, empty when the file does not say.
+	Colors []string
+}
+
+// Picker chooses one of several ROMs found in an archive and returns its
+// index.
+type Picker func(names []string) (int, error)
+
+// Decode detects the format of a ROM file and returns the program in it:
+// a ZIP archive, an Octo cartridge GIF, an Octo source file, a hex text
+// listing, or else raw bytes. When an archive holds several ROMs, pick
+// chooses one; a nil pick makes that an error listing them.
+func Decode(name string, data []byte, pick Picker) (*Image, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return decodeZip(data, pick)
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return decodeCartridge(name, data)
+	case strings.EqualFold(path.Ext(name), ".8o"):
+		program, err := AssembleOcto(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return &Image{Name: name, Data: program}, nil
+	}
+
+	if program, ok := parseHexListing(data); ok {
+		return &Image{Name: name, Data: program}, nil
+	}
+
+	return &Image{Name: name, Data: data}, nil
+}
+
+func decodeZip(data []byte, pick Picker) (*Image, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ZIP archive: %v", err)
+	}
+
+	var files []*zip.File
+	var names []string
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !isRomName(file.Name) {
+			continue
+		}
+		// Text files only count when they are hex listings, so that the
+		// README shipped next to a ROM is not taken for another one.
+		if strings.EqualFold(path.Ext(file.Name), ".txt") && !isHexListingFile(file) {
+			continue
+		}
+		files = append(files, file)
+		names = append(names, file.Name)
+	}
+
+	index := 0
+	switch {
+	case len(files) == 0:
+		return nil, fmt.Errorf("no ROM found in ZIP archive (looked for %s)", strings.Join(RomExtensions, ", "))
+	case len(files) > 1 && pick == nil:
+		return nil, fmt.Errorf("ZIP archive holds several ROMs: %s", strings.Join(names, ", "))
+	case len(files) > 1:
+		if index, err = pick(names); err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(files) {
+			return nil, fmt.Errorf("no ROM number %d in ZIP archive", index+1)
+		}
+	}
+
+	content, err := readZipFile(files[index])
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %v", names[index], err)
+	}
+
+	// Archives inside archives are not unpacked.
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		return nil, fmt.Errorf("%s is itself a ZIP archive", names[index])
+	}
+
+	return Decode(names[index], content, nil)
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func isHexListingFile(file *zip.File) bool {
+	content, err := readZipFile(file)
+	if err != nil {
+		return false
+	}
+	_, ok := parseHexListing(content)
+	return ok
+}
+
+func isRomName(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, romExt := range RomExtensions {
+		if ext == romExt {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHexListing reads ROMs written out as text: hex bytes or words
+// separated by spaces or commas, optionally with "0x" prefixes, address
+// labels ending in ':' and comments starting with '#', ';' or "//".
+func parseHexListing(data []byte) ([]byte, bool) {
+	for _, b := range data {
+		if b >= 0x80 || (b < 0x20 && !unicode.IsSpace(rune(b))) {
+			return nil, false
+		}
+	}
+
+	var program []byte
+	for _, line := range strings.Split(string(data), "\n") {
+		for _, marker := range []string{"#", ";", "//"} {
+			if i := strings.Index(line, marker); i >= 0 {
+				line = line[:i]
+			}
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		for _, field := range fields {
+			if strings.HasSuffix(field, ":") {
+				continue
+			}
+
+			field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+			if len(field) == 0 || len(field)%2 != 0 {
+				return nil, false
+			}
+			for i := 0; i < len(field); i += 2 {
+				value, err := strconv.ParseUint(field[i:i+2], 16, 8)
+				if err != nil {
+					return nil, false
+				}
+				program = append(program, uint8(value))
+			}
+		}
+	}
+
+	return program, len(program) > 0
+}
@@ -0,0 +1,68 @@
+package rom
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeZipTextFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		isError  bool
+	}{
+		{
+			name:     "README next to a ROM",
+			files:    map[string]string{"game.ch8": "\x12\x00", "README.txt": "A game by someone.\n"},
+			expected: "game.ch8",
+		},
+		{
+			name:     "hex listing",
+			files:    map[string]string{"game.txt": "12 00\n", "README.md": "# A game\n"},
+			expected: "game.txt",
+		},
+		{
+			name:    "ROM and hex listing",
+			files:   map[string]string{"game.ch8": "\x12\x00", "other.txt": "12 00\n"},
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, err := Decode("games.zip", zipArchive(t, test.files), nil)
+			if test.isError {
+				if err == nil {
+					t.Fatalf("picked %s from several ROMs without a picker", image.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image.Name != test.expected || !bytes.Equal(image.Data, []byte{0x12, 0x00}) {
+				t.Errorf("picked %s % X, expected %s", image.Name, image.Data, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package rom
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	IPS_MAGIC = "PATCH"
+	IPS_EOF   = "EOF"
+)
+
+// applyIPS applies an IPS patch: a list of records writing bytes, or runs
+// of one byte, at 24-bit offsets, optionally followed by a truncation size.
+func applyIPS(data, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(IPS_MAGIC)) {
+		return nil, fmt.Errorf("not an IPS patch")
+	}
+
+	output := bytes.Clone(data)
+	pos := len(IPS_MAGIC)
+
+	read := func(n int) ([]byte, error) {
+		if pos+n > len(patch) {
+			return nil, fmt.Errorf("truncated IPS patch at offset %d", pos)
+		}
+		chunk := patch[pos : pos+n]
+		pos += n
+		return chunk, nil
+	}
+
+	for {
+		header, err := read(3)
+		if err != nil {
+			return nil, err
+		}
+		if string(header) == IPS_EOF {
+			break
+		}
+		offset := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+
+		sizeBytes, err := read(2)
+		if err != nil {
+			return nil, err
+		}
+		size := int(sizeBytes[0])<<8 | int(sizeBytes[1])
+
+		var chunk []byte
+		if size == 0 {
+			run, err := read(3)
+			if err != nil {
+				return nil, err
+			}
+			chunk = bytes.Repeat(run[2:3], int(run[0])<<8|int(run[1]))
+		} else if chunk, err = read(size); err != nil {
+			return nil, err
+		}
+
+		end := offset + len(chunk)
+		if end > MAX_PATCHED_SIZE {
+			return nil, fmt.Errorf("IPS patch writes at %06X, past the %d bytes of memory", end-1, MAX_PATCHED_SIZE)
+		}
+		if end > len(output) {
+			output = append(output, make([]byte, end-len(output))...)
+		}
+		copy(output[offset:], chunk)
+	}
+
+	if truncate, err := read(3); err == nil {
+		size := int(truncate[0])<<16 | int(truncate[1])<<8 | int(truncate[2])
+		if size < len(output) {
+			output = output[:size]
+		}
+	}
+
+	return output, nil
+}
@@ -0,0 +1,75 @@
+package rom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/gif"
+)
+
+// cartridge is the JSON payload of an Octo cartridge.
+type cartridge struct {
+	Program string `json:"program"`
+	Options struct {
+		TickRate        int    `json:"tickrate"`
+		BackgroundColor string `json:"backgroundColor"`
+		FillColor       string `json:"fillColor"`
+		FillColor2      string `json:"fillColor2"`
+		BlendColor      string `json:"blendColor"`
+	} `json:"options"`
+}
+
+// decodeCartridge reads an Octo cartridge: a GIF whose frames carry, two
+// pixels per byte in the low nibble of each palette index, a big-endian
+// length followed by a JSON object holding the program source and its
+// options.
+func decodeCartridge(name string, data []byte) (*Image, error) {
+	image, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cartridge GIF: %v", err)
+	}
+
+	var nibbles []byte
+	for _, frame := range image.Image {
+		bounds := frame.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				nibbles = append(nibbles, frame.ColorIndexAt(x, y)&0x0F)
+			}
+		}
+	}
+
+	payload := make([]byte, len(nibbles)/2)
+	for i := range payload {
+		payload[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("not an Octo cartridge: no payload")
+	}
+	size := int(payload[0])<<24 | int(payload[1])<<16 | int(payload[2])<<8 | int(payload[3])
+	if size > len(payload)-4 {
+		return nil, fmt.Errorf("not an Octo cartridge: payload of %d bytes in a %d byte image", size, len(payload)-4)
+	}
+
+	var cart cartridge
+	if err := json.Unmarshal(payload[4:4+size], &cart); err != nil {
+		return nil, fmt.Errorf("not an Octo cartridge: %v", err)
+	}
+
+	program, err := AssembleOcto(cart.Program)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assemble cartridge program: %v", err)
+	}
+
+	img := &Image{Name: name, Data: program, TickRate: cart.Options.TickRate}
+	options := cart.Options
+	if options.BackgroundColor != "" && options.FillColor != "" {
+		img.Colors = []string{options.BackgroundColor, options.FillColor}
+		if options.FillColor2 != "" && options.BlendColor != "" {
+			img.Colors = append(img.Colors, options.FillColor2, options.BlendColor)
+		}
+	}
+
+	return img, nil
+}
@@ -0,0 +1,172 @@
+package rom
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readGolden reads a program written as hex words separated by spaces.
+func readGolden(t *testing.T, path string) []byte {
+	t.Helper()
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := hex.DecodeString(strings.Join(strings.Fields(string(text)), ""))
+	if err != nil {
+		t.Fatalf("invalid golden file %s: %v", path, err)
+	}
+	return program
+}
+
+func TestAssembleOctoGolden(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "octo", "*.8o"))
+	if err != nil || len(sources) == 0 {
+		t.Fatalf("no Octo sources in testdata: %v", err)
+	}
+
+	for _, source := range sources {
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			data, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			image, err := Decode(filepath.Base(source), data, nil)
+			if err != nil {
+				t.Fatalf("failed to assemble: %v", err)
+			}
+
+			expected := readGolden(t, strings.TrimSuffix(source, ".8o")+".hex")
+			if !bytes.Equal(image.Data, expected) {
+				t.Errorf("assembled\n% X\nexpected\n% X", image.Data, expected)
+			}
+		})
+	}
+}
+
+func TestAssembleOctoErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"macro", ": main\n:macro twice X { X X }", ":macro is not supported"},
+		{"calc", ": main\n:calc SIZE { 2 * 4 }", ":calc is not supported"},
+		{"long index", ": main\ni := long data", "i := long is not supported"},
+		{"undefined label", ": main\njump nowhere", `line 2: undefined label "nowhere"`},
+		{"no main", "v0 := 1", "no main label"},
+		{"label twice", ": main\n: main", `label "main" defined twice`},
+		{"unclosed loop", ": main\nloop\nv0 += 1", "missing again"},
+		{"unclosed begin", ": main\nif v0 == 1 begin", "missing end"},
+		{"byte too large", ": main\nv0 := 300", `value "300" does not fit in a byte`},
+		{"unknown alias", ": main\n:alias x v3\ny := 1", `unexpected ":="`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := AssembleOcto(test.source)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
+
+// octoCartridge encodes payload like Octo does, after its big-endian
+// length.
+func octoCartridge(t *testing.T, payload []byte) []byte {
+	t.Helper()
+
+	size := len(payload)
+	return cartridgeGif(t, append([]byte{byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}, payload...))
+}
+
+// cartridgeGif stores data two nibbles per pixel, in the low bits of the
+// palette indices.
+func cartridgeGif(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	palette := make(color.Palette, 16)
+	for i := range palette {
+		palette[i] = color.Gray{Y: uint8(i * 17)}
+	}
+
+	const width = 32
+	frame := image.NewPaletted(image.Rect(0, 0, width, (len(data)*2+width-1)/width), palette)
+	for i, b := range data {
+		frame.Pix[2*i] = b >> 4
+		frame.Pix[2*i+1] = b & 0x0F
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame}, Delay: []int{0}}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeCartridge(t *testing.T) {
+	source, err := os.ReadFile(filepath.Join("testdata", "octo", "labels.8o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"program": string(source),
+		"options": map[string]any{
+			"tickrate":        20,
+			"backgroundColor": "#000000",
+			"fillColor":       "#FFCC00",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := Decode("game.gif", octoCartridge(t, payload), nil)
+	if err != nil {
+		t.Fatalf("failed to decode cartridge: %v", err)
+	}
+
+	expected := readGolden(t, filepath.Join("testdata", "octo", "labels.hex"))
+	if !bytes.Equal(image.Data, expected) {
+		t.Errorf("program\n% X\nexpected\n% X", image.Data, expected)
+	}
+	if image.TickRate != 20 {
+		t.Errorf("tick rate %d, expected 20", image.TickRate)
+	}
+	if strings.Join(image.Colors, ",") != "#000000,#FFCC00" {
+		t.Errorf("colours %q, expected the background and fill colours", image.Colors)
+	}
+}
+
+func TestDecodeCartridgeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"not JSON", octoCartridge(t, []byte("not json")), "not an Octo cartridge"},
+		{"program not assembling", octoCartridge(t, []byte(`{"program": "v0 := 1"}`)), "no main label"},
+		{"length past the image", cartridgeGif(t, []byte{0x00, 0x00, 0x10, 0x00, '{', '}'}), "payload of 4096 bytes"},
+		{"broken GIF", []byte("GIF89a\x01"), "failed to decode cartridge GIF"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode("game.gif", test.data, nil)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,708 @@
+package rom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OCTO_START is where assembled Octo programs are placed; the first
+// instruction is a jump to the "main" label.
+const OCTO_START = 0x200
+
+// octoUnsupported are the Octo directives AssembleOcto rejects.
+var octoUnsupported = []string{":macro", ":calc", ":byte{", ":next", ":stringmode", ":assert", ":pointer", ":proto"}
+
+type fixupKind int
+
+const (
+	fixupAddress fixupKind = iota
+	fixupHighNibble
+	fixupLowByte
+)
+
+type octoFixup struct {
+	addr int
+	name string
+	kind fixupKind
+	line int
+}
+
+type octoToken struct {
+	text string
+	line int
+}
+
+// octoBlock is an open loop or if/begin block. patches are the jumps to
+// point at the block's end.
+type octoBlock struct {
+	isLoop  bool
+	start   int
+	patches []int
+}
+
+// octoCondition is an if or while condition: the instructions computing
+// it, followed by one skipping the next instruction when it is true or
+// false.
+type octoCondition struct {
+	prelude       []uint16
+	skipWhenTrue  uint16
+	skipWhenFalse uint16
+}
+
+type octoAssembler struct {
+	tokens  []octoToken
+	pos     int
+	here    int
+	program []byte
+	labels  map[string]int
+	consts  map[string]int
+	aliases map[string]int
+	fixups  []octoFixup
+	blocks  []*octoBlock
+}
+
+// AssembleOcto assembles Octo source into a CHIP-8 program loaded at
+// OCTO_START. It covers labels, :const, :alias, :org, :byte, :call,
+// :unpack, plain data bytes, every instruction, if/then, if/begin/else/end
+// and loop/while/again; macros and :calc expressions are not supported.
+func AssembleOcto(source string) ([]byte, error) {
+	a := &octoAssembler{
+		here:    OCTO_START,
+		labels:  map[string]int{},
+		consts:  map[string]int{},
+		aliases: map[string]int{},
+	}
+
+	for i, line := range strings.Split(source, "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		for _, field := range strings.Fields(line) {
+			a.tokens = append(a.tokens, octoToken{text: field, line: i + 1})
+		}
+	}
+
+	a.emit(0x1000)
+	a.fixups = append(a.fixups, octoFixup{addr: OCTO_START, name: "main", kind: fixupAddress})
+
+	for a.pos < len(a.tokens) {
+		if err := a.statement(); err != nil {
+			return nil, fmt.Errorf("line %d: %v", a.tokens[min(a.pos, len(a.tokens)-1)].line, err)
+		}
+	}
+
+	if len(a.blocks) > 0 {
+		if a.blocks[len(a.blocks)-1].isLoop {
+			return nil, fmt.Errorf("missing again at end of program")
+		}
+		return nil, fmt.Errorf("missing end at end of program")
+	}
+
+	for _, fixup := range a.fixups {
+		addr, ok := a.labels[fixup.name]
+		if !ok {
+			if fixup.name == "main" {
+				return nil, fmt.Errorf("no main label")
+			}
+			return nil, fmt.Errorf("line %d: undefined label %q", fixup.line, fixup.name)
+		}
+
+		offset := fixup.addr - OCTO_START
+		switch fixup.kind {
+		case fixupAddress:
+			a.program[offset] |= uint8(addr>>8) & 0x0F
+			a.program[offset+1] = uint8(addr)
+		case fixupHighNibble:
+			a.program[offset+1] |= uint8(addr>>8) & 0x0F
+		case fixupLowByte:
+			a.program[offset+1] = uint8(addr)
+		}
+	}
+
+	return a.program, nil
+}
+
+func (a *octoAssembler) next() (string, error) {
+	if a.pos >= len(a.tokens) {
+		return "", fmt.Errorf("unexpected end of program")
+	}
+	a.pos++
+	return a.tokens[a.pos-1].text, nil
+}
+
+func (a *octoAssembler) expect(want string) error {
+	token, err := a.next()
+	if err != nil {
+		return err
+	}
+	if token != want {
+		return fmt.Errorf("expected %q, found %q", want, token)
+	}
+	return nil
+}
+
+func (a *octoAssembler) emitByte(value uint8) {
+	offset := a.here - OCTO_START
+	for len(a.program) <= offset {
+		a.program = append(a.program, 0)
+	}
+	a.program[offset] = value
+	a.here++
+}
+
+func (a *octoAssembler) emit(opcode uint16) {
+	a.emitByte(uint8(opcode >> 8))
+	a.emitByte(uint8(opcode))
+}
+
+// emitAddress emits an opcode taking a 12-bit address, resolved later when
+// it names a label not defined yet.
+func (a *octoAssembler) emitAddress(opcode uint16, token string) error {
+	if addr, ok := a.lookup(token); ok {
+		if addr < 0 || addr > 0xFFF {
+			return fmt.Errorf("address %q out of range", token)
+		}
+		a.emit(opcode | uint16(addr))
+		return nil
+	}
+	if !isOctoName(token) {
+		return fmt.Errorf("invalid address %q", token)
+	}
+
+	a.fixups = append(a.fixups, octoFixup{addr: a.here, name: token, kind: fixupAddress, line: a.tokens[a.pos-1].line})
+	a.emit(opcode)
+	return nil
+}
+
+func (a *octoAssembler) lookup(token string) (int, bool) {
+	if value, err := strconv.ParseInt(token, 0, 32); err == nil {
+		return int(value), true
+	}
+	if value, ok := a.consts[token]; ok {
+		return value, true
+	}
+	value, ok := a.labels[token]
+	return value, ok
+}
+
+func (a *octoAssembler) byteValue(token string) (uint16, error) {
+	value, ok := a.lookup(token)
+	if !ok {
+		return 0, fmt.Errorf("unknown value %q", token)
+	}
+	if value < -128 || value > 0xFF {
+		return 0, fmt.Errorf("value %q does not fit in a byte", token)
+	}
+	return uint16(uint8(value)), nil
+}
+
+func (a *octoAssembler) register(token string) (uint16, bool) {
+	if n, ok := a.aliases[token]; ok {
+		return uint16(n), true
+	}
+	lower := strings.ToLower(token)
+	if len(lower) == 2 && lower[0] == 'v' {
+		if n, err := strconv.ParseUint(lower[1:], 16, 8); err == nil {
+			return uint16(n), true
+		}
+	}
+	return 0, false
+}
+
+func (a *octoAssembler) nextRegister() (uint16, error) {
+	token, err := a.next()
+	if err != nil {
+		return 0, err
+	}
+	x, ok := a.register(token)
+	if !ok {
+		return 0, fmt.Errorf("expected a register, found %q", token)
+	}
+	return x, nil
+}
+
+func isOctoName(token string) bool {
+	if token == "" || strings.ContainsAny(token[:1], "0123456789-:") {
+		return false
+	}
+	return !strings.ContainsAny(token, "{}()\"")
+}
+
+func (a *octoAssembler) statement() error {
+	token, err := a.next()
+	if err != nil {
+		return err
+	}
+
+	for _, directive := range octoUnsupported {
+		if strings.HasPrefix(token, directive) {
+			return fmt.Errorf("%s is not supported", token)
+		}
+	}
+
+	if x, ok := a.register(token); ok {
+		return a.registerStatement(x)
+	}
+
+	switch token {
+	case ":":
+		name, err := a.next()
+		if err != nil {
+			return err
+		}
+		if _, ok := a.labels[name]; ok {
+			return fmt.Errorf("label %q defined twice", name)
+		}
+		a.labels[name] = a.here
+		return nil
+
+	case ":const", ":alias":
+		name, err := a.next()
+		if err != nil {
+			return err
+		}
+		value, err := a.next()
+		if err != nil {
+			return err
+		}
+		if token == ":alias" {
+			x, ok := a.register(value)
+			if !ok {
+				return fmt.Errorf("expected a register, found %q", value)
+			}
+			a.aliases[name] = int(x)
+			return nil
+		}
+		n, ok := a.lookup(value)
+		if !ok {
+			return fmt.Errorf("unknown value %q", value)
+		}
+		a.consts[name] = n
+		return nil
+
+	case ":org":
+		value, err := a.next()
+		if err != nil {
+			return err
+		}
+		addr, ok := a.lookup(value)
+		if !ok || addr < OCTO_START || addr > 0xFFFF {
+			return fmt.Errorf("invalid origin %q", value)
+		}
+		a.here = addr
+		return nil
+
+	case ":byte":
+		value, err := a.next()
+		if err != nil {
+			return err
+		}
+		n, err := a.byteValue(value)
+		if err != nil {
+			return err
+		}
+		a.emitByte(uint8(n))
+		return nil
+
+	case ":call":
+		target, err := a.next()
+		if err != nil {
+			return err
+		}
+		return a.emitAddress(0x2000, target)
+
+	case ":unpack":
+		return a.unpack()
+
+	case ":breakpoint":
+		_, err := a.next()
+		return err
+
+	case ":monitor":
+		if _, err := a.next(); err != nil {
+			return err
+		}
+		_, err := a.next()
+		return err
+
+	case "clear":
+		a.emit(0x00E0)
+	case "return", ";":
+		a.emit(0x00EE)
+	case "exit":
+		a.emit(0x00FD)
+	case "hires":
+		a.emit(0x00FF)
+	case "lores":
+		a.emit(0x00FE)
+	case "scroll-left":
+		a.emit(0x00FC)
+	case "scroll-right":
+		a.emit(0x00FB)
+
+	case "scroll-down", "scroll-up":
+		value, err := a.next()
+		if err != nil {
+			return err
+		}
+		n, err := a.byteValue(value)
+		if err != nil || n > 0xF {
+			return fmt.Errorf("invalid scroll amount %q", value)
+		}
+		if token == "scroll-down" {
+			a.emit(0x00C0 | n)
+		} else {
+			a.emit(0x00D0 | n)
+		}
+
+	case "jump", "jump0":
+		target, err := a.next()
+		if err != nil {
+			return err
+		}
+		if token == "jump" {
+			return a.emitAddress(0x1000, target)
+		}
+		return a.emitAddress(0xB000, target)
+
+	case "sprite":
+		x, err := a.nextRegister()
+		if err != nil {
+			return err
+		}
+		y, err := a.nextRegister()
+		if err != nil {
+			return err
+		}
+		value, err := a.next()
+		if err != nil {
+			return err
+		}
+		n, err := a.byteValue(value)
+		if err != nil || n > 0xF {
+			return fmt.Errorf("invalid sprite height %q", value)
+		}
+		a.emit(0xD000 | x<<8 | y<<4 | n)
+
+	case "bcd", "save", "load":
+		x, err := a.nextRegister()
+		if err != nil {
+			return err
+		}
+		a.emit(map[string]uint16{"bcd": 0xF033, "save": 0xF055, "load": 0xF065}[token] | x<<8)
+
+	case "delay", "buzzer":
+		if err := a.expect(":="); err != nil {
+			return err
+		}
+		x, err := a.nextRegister()
+		if err != nil {
+			return err
+		}
+		if token == "delay" {
+			a.emit(0xF015 | x<<8)
+		} else {
+			a.emit(0xF018 | x<<8)
+		}
+
+	case "i":
+		return a.indexStatement()
+
+	case "if":
+		return a.ifStatement()
+
+	case "else":
+		if len(a.blocks) == 0 || a.blocks[len(a.blocks)-1].isLoop {
+			return fmt.Errorf("else without if ... begin")
+		}
+		block := a.blocks[len(a.blocks)-1]
+		skip := block.patches
+		block.patches = []int{a.here}
+		a.emit(0x1000)
+		a.patch(skip, a.here)
+
+	case "end":
+		if len(a.blocks) == 0 || a.blocks[len(a.blocks)-1].isLoop {
+			return fmt.Errorf("end without if ... begin")
+		}
+		a.patch(a.blocks[len(a.blocks)-1].patches, a.here)
+		a.blocks = a.blocks[:len(a.blocks)-1]
+
+	case "loop":
+		a.blocks = append(a.blocks, &octoBlock{isLoop: true, start: a.here})
+
+	case "while":
+		var loop *octoBlock
+		for i := len(a.blocks) - 1; i >= 0 && loop == nil; i-- {
+			if a.blocks[i].isLoop {
+				loop = a.blocks[i]
+			}
+		}
+		if loop == nil {
+			return fmt.Errorf("while outside of a loop")
+		}
+		condition, err := a.condition()
+		if err != nil {
+			return err
+		}
+		a.emitCondition(condition, condition.skipWhenTrue)
+		loop.patches = append(loop.patches, a.here)
+		a.emit(0x1000)
+
+	case "again":
+		if len(a.blocks) == 0 || !a.blocks[len(a.blocks)-1].isLoop {
+			return fmt.Errorf("again without loop")
+		}
+		loop := a.blocks[len(a.blocks)-1]
+		a.emit(0x1000 | uint16(loop.start))
+		a.patch(loop.patches, a.here)
+		a.blocks = a.blocks[:len(a.blocks)-1]
+
+	default:
+		if value, err := strconv.ParseInt(token, 0, 32); err == nil {
+			if value < -128 || value > 0xFF {
+				return fmt.Errorf("value %q does not fit in a byte", token)
+			}
+			a.emitByte(uint8(value))
+			return nil
+		}
+		if !isOctoName(token) {
+			return fmt.Errorf("unexpected %q", token)
+		}
+		return a.emitAddress(0x2000, token)
+	}
+
+	return nil
+}
+
+func (a *octoAssembler) patch(jumps []int, target int) {
+	for _, addr := range jumps {
+		offset := addr - OCTO_START
+		a.program[offset] = 0x10 | uint8(target>>8)&0x0F
+		a.program[offset+1] = uint8(target)
+	}
+}
+
+func (a *octoAssembler) registerStatement(x uint16) error {
+	op, err := a.next()
+	if err != nil {
+		return err
+	}
+	operand, err := a.next()
+	if err != nil {
+		return err
+	}
+
+	y, isRegister := a.register(operand)
+
+	switch {
+	case op == ":=" && operand == "random":
+		mask, err := a.next()
+		if err != nil {
+			return err
+		}
+		n, err := a.byteValue(mask)
+		if err != nil {
+			return err
+		}
+		a.emit(0xC000 | x<<8 | n)
+	case op == ":=" && operand == "key":
+		a.emit(0xF00A | x<<8)
+	case op == ":=" && operand == "delay":
+		a.emit(0xF007 | x<<8)
+
+	case isRegister:
+		aluOps := map[string]uint16{":=": 0x0, "|=": 0x1, "&=": 0x2, "^=": 0x3, "+=": 0x4, "-=": 0x5, ">>=": 0x6, "=-": 0x7, "<<=": 0xE}
+		aluOp, ok := aluOps[op]
+		if !ok {
+			return fmt.Errorf("unknown operator %q", op)
+		}
+		a.emit(0x8000 | x<<8 | y<<4 | aluOp)
+
+	default:
+		n, err := a.byteValue(operand)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case ":=":
+			a.emit(0x6000 | x<<8 | n)
+		case "+=":
+			a.emit(0x7000 | x<<8 | n)
+		case "-=":
+			a.emit(0x7000 | x<<8 | (-n)&0xFF)
+		default:
+			return fmt.Errorf("operator %q needs a register operand", op)
+		}
+	}
+
+	return nil
+}
+
+func (a *octoAssembler) indexStatement() error {
+	op, err := a.next()
+	if err != nil {
+		return err
+	}
+	operand, err := a.next()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case op == "+=":
+		x, ok := a.register(operand)
+		if !ok {
+			return fmt.Errorf("expected a register, found %q", operand)
+		}
+		a.emit(0xF01E | x<<8)
+	case op == ":=" && (operand == "hex" || operand == "bighex"):
+		x, err := a.nextRegister()
+		if err != nil {
+			return err
+		}
+		if operand == "hex" {
+			a.emit(0xF029 | x<<8)
+		} else {
+			a.emit(0xF030 | x<<8)
+		}
+	case op == ":=" && operand == "long":
+		return fmt.Errorf("i := long is not supported")
+	case op == ":=":
+		return a.emitAddress(0xA000, operand)
+	default:
+		return fmt.Errorf("unknown operator %q for i", op)
+	}
+
+	return nil
+}
+
+func (a *octoAssembler) unpack() error {
+	value, err := a.next()
+	if err != nil {
+		return err
+	}
+	n, err := a.byteValue(value)
+	if err != nil || n > 0xF {
+		return fmt.Errorf("invalid unpack nibble %q", value)
+	}
+	target, err := a.next()
+	if err != nil {
+		return err
+	}
+
+	if addr, ok := a.lookup(target); ok {
+		a.emit(0x6000 | n<<4 | uint16(addr>>8)&0x0F)
+		a.emit(0x6100 | uint16(addr)&0xFF)
+		return nil
+	}
+
+	line := a.tokens[a.pos-1].line
+	a.fixups = append(a.fixups, octoFixup{addr: a.here, name: target, kind: fixupHighNibble, line: line})
+	a.emit(0x6000 | n<<4)
+	a.fixups = append(a.fixups, octoFixup{addr: a.here, name: target, kind: fixupLowByte, line: line})
+	a.emit(0x6100)
+	return nil
+}
+
+func (a *octoAssembler) ifStatement() error {
+	condition, err := a.condition()
+	if err != nil {
+		return err
+	}
+
+	token, err := a.next()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case "then":
+		a.emitCondition(condition, condition.skipWhenFalse)
+	case "begin":
+		a.emitCondition(condition, condition.skipWhenTrue)
+		a.blocks = append(a.blocks, &octoBlock{patches: []int{a.here}})
+		a.emit(0x1000)
+	default:
+		return fmt.Errorf("expected then or begin, found %q", token)
+	}
+
+	return nil
+}
+
+func (a *octoAssembler) emitCondition(condition octoCondition, skip uint16) {
+	for _, opcode := range condition.prelude {
+		a.emit(opcode)
+	}
+	a.emit(skip)
+}
+
+func (a *octoAssembler) condition() (octoCondition, error) {
+	x, err := a.nextRegister()
+	if err != nil {
+		return octoCondition{}, err
+	}
+	op, err := a.next()
+	if err != nil {
+		return octoCondition{}, err
+	}
+
+	switch op {
+	case "key":
+		return octoCondition{skipWhenTrue: 0xE09E | x<<8, skipWhenFalse: 0xE0A1 | x<<8}, nil
+	case "-key":
+		return octoCondition{skipWhenTrue: 0xE0A1 | x<<8, skipWhenFalse: 0xE09E | x<<8}, nil
+	}
+
+	operand, err := a.next()
+	if err != nil {
+		return octoCondition{}, err
+	}
+	y, isRegister := a.register(operand)
+	var n uint16
+	if !isRegister {
+		if n, err = a.byteValue(operand); err != nil {
+			return octoCondition{}, err
+		}
+	}
+
+	var condition octoCondition
+	switch op {
+	case "==", "!=":
+		if isRegister {
+			condition = octoCondition{skipWhenTrue: 0x5000 | x<<8 | y<<4, skipWhenFalse: 0x9000 | x<<8 | y<<4}
+		} else {
+			condition = octoCondition{skipWhenTrue: 0x3000 | x<<8 | n, skipWhenFalse: 0x4000 | x<<8 | n}
+		}
+		if op == "!=" {
+			condition.skipWhenTrue, condition.skipWhenFalse = condition.skipWhenFalse, condition.skipWhenTrue
+		}
+		return condition, nil
+
+	case "<", ">", "<=", ">=":
+		// vf ends up 1 when the left side of the subtraction is greater or
+		// equal to the right side: ">=" and "<" test x >= operand, ">"
+		// and "<=" test operand >= x.
+		isSwapped := op == ">" || op == "<="
+		switch {
+		case isRegister && !isSwapped:
+			condition.prelude = []uint16{0x8F00 | x<<4, 0x8F05 | y<<4}
+		case isRegister:
+			condition.prelude = []uint16{0x8F00 | y<<4, 0x8F05 | x<<4}
+		case !isSwapped:
+			condition.prelude = []uint16{0x6F00 | n, 0x8F07 | x<<4}
+		default:
+			condition.prelude = []uint16{0x6F00 | n, 0x8F05 | x<<4}
+		}
+
+		flag := uint16(1)
+		if op == "<" || op == ">" {
+			flag = 0
+		}
+		condition.skipWhenTrue = 0x3F00 | flag
+		condition.skipWhenFalse = 0x4F00 | flag
+		return condition, nil
+	}
+
+	return octoCondition{}, fmt.Errorf("unknown comparison %q", op)
+}
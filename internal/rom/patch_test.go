@@ -0,0 +1,242 @@
+package rom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// bpsNumber encodes n as a BPS number.
+func bpsNumber(n int) []byte {
+	var encoded []byte
+	for {
+		x := byte(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(encoded, x|0x80)
+		}
+		encoded = append(encoded, x)
+		n--
+	}
+}
+
+// bpsPatch builds a BPS patch from its header numbers and actions, with the
+// checksums of source and target and a valid checksum of its own.
+func bpsPatch(source, target []byte, header [][]byte, actions ...[]byte) []byte {
+	patch := []byte(BPS_MAGIC)
+	for _, number := range header {
+		patch = append(patch, number...)
+	}
+	for _, action := range actions {
+		patch = append(patch, action...)
+	}
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(source))
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(patch))
+}
+
+// bpsAction encodes the header of an action of length bytes.
+func bpsAction(action, length int) []byte {
+	return bpsNumber((length-1)<<2 | action)
+}
+
+func bpsSizes(source, target, metadata int) [][]byte {
+	return [][]byte{bpsNumber(source), bpsNumber(target), bpsNumber(metadata)}
+}
+
+func TestApplyBPS(t *testing.T) {
+	source := []byte("ABCD")
+	target := []byte("ABXYCDCD")
+	valid := bpsPatch(source, target, bpsSizes(4, 8, 0),
+		bpsAction(bpsSourceRead, 2),
+		bpsAction(bpsTargetRead, 2), []byte("XY"),
+		bpsAction(bpsSourceCopy, 2), bpsNumber(2<<1),
+		bpsAction(bpsTargetCopy, 2), bpsNumber(4<<1),
+	)
+
+	tests := []struct {
+		name     string
+		source   []byte
+		patch    []byte
+		expected []byte
+		isError  bool
+	}{
+		{
+			name:     "every action",
+			source:   source,
+			patch:    valid,
+			expected: target,
+		},
+		{
+			name:     "metadata skipped",
+			source:   source,
+			patch:    bpsPatch(source, source, bpsSizes(4, 4, 3), []byte("abc"), bpsAction(bpsSourceRead, 4)),
+			expected: source,
+		},
+		{
+			name:    "another source",
+			source:  []byte("ABCE"),
+			patch:   valid,
+			isError: true,
+		},
+		{
+			name:    "corrupted patch",
+			source:  source,
+			patch:   append(bytes.Clone(valid[:8]), valid[9:]...),
+			isError: true,
+		},
+		{
+			name:    "shorter than its footer",
+			source:  source,
+			patch:   []byte(BPS_MAGIC + "\x84\x84"),
+			isError: true,
+		},
+		{
+			name:    "truncated number",
+			source:  source,
+			patch:   bpsPatch(source, source, [][]byte{bpsNumber(4), {0x04}}),
+			isError: true,
+		},
+		{
+			name:    "truncated target read",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 0), bpsAction(bpsTargetRead, 4), []byte("AB")),
+			isError: true,
+		},
+		{
+			name:    "number longer than nine bytes",
+			source:  source,
+			patch:   bpsPatch(source, source, [][]byte{bpsNumber(4), bytes.Repeat([]byte{0x7F}, 9), {0x80}, bpsNumber(0)}),
+			isError: true,
+		},
+		{
+			name:    "number overflowing",
+			source:  source,
+			patch:   bpsPatch(source, source, [][]byte{bpsNumber(4), append(bytes.Repeat([]byte{0x7F}, 8), 0xFF), bpsNumber(0)}),
+			isError: true,
+		},
+		{
+			name:    "target larger than memory",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 1<<40, 0), bpsAction(bpsSourceRead, 4)),
+			isError: true,
+		},
+		{
+			name:    "metadata past the end",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 1<<40), bpsAction(bpsSourceRead, 4)),
+			isError: true,
+		},
+		{
+			name:    "action longer than the target",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 0), bpsNumber(1<<60|bpsTargetRead)),
+			isError: true,
+		},
+		{
+			name:    "source copy before the source",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 0), bpsAction(bpsSourceCopy, 4), bpsNumber(1<<1|1)),
+			isError: true,
+		},
+		{
+			name:    "source copy far past the source",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 0), bpsAction(bpsSourceCopy, 4), bpsNumber(1<<61)),
+			isError: true,
+		},
+		{
+			name:    "target copy of unwritten bytes",
+			source:  source,
+			patch:   bpsPatch(source, source, bpsSizes(4, 4, 0), bpsAction(bpsTargetCopy, 4), bpsNumber(0)),
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := ApplyPatch(test.source, test.patch)
+			if test.isError != (err != nil) {
+				t.Fatalf("error %v, expected one: %t", err, test.isError)
+			}
+			if !bytes.Equal(output, test.expected) {
+				t.Errorf("patched to %q, expected %q", output, test.expected)
+			}
+		})
+	}
+}
+
+func TestApplyIPS(t *testing.T) {
+	source := []byte("ABCDEF")
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected []byte
+		isError  bool
+	}{
+		{
+			name:     "record",
+			patch:    IPS_MAGIC + "\x00\x00\x01\x00\x02xy" + IPS_EOF,
+			expected: []byte("AxyDEF"),
+		},
+		{
+			name:     "run",
+			patch:    IPS_MAGIC + "\x00\x00\x02\x00\x00\x00\x03z" + IPS_EOF,
+			expected: []byte("ABzzzF"),
+		},
+		{
+			name:     "record past the end",
+			patch:    IPS_MAGIC + "\x00\x00\x08\x00\x01!" + IPS_EOF,
+			expected: []byte("ABCDEF\x00\x00!"),
+		},
+		{
+			name:     "truncation",
+			patch:    IPS_MAGIC + IPS_EOF + "\x00\x00\x03",
+			expected: []byte("ABC"),
+		},
+		{
+			name:    "not a patch",
+			patch:   "PATCJ" + IPS_EOF,
+			isError: true,
+		},
+		{
+			name:    "missing EOF",
+			patch:   IPS_MAGIC + "\x00\x00\x01\x00\x01x",
+			isError: true,
+		},
+		{
+			name:    "truncated record",
+			patch:   IPS_MAGIC + "\x00\x00\x01\x00\x04xy",
+			isError: true,
+		},
+		{
+			name:    "truncated run",
+			patch:   IPS_MAGIC + "\x00\x00\x01\x00\x00\x00",
+			isError: true,
+		},
+		{
+			name:    "record past memory",
+			patch:   IPS_MAGIC + "\xFF\xFF\xFF\x00\x01x" + IPS_EOF,
+			isError: true,
+		},
+		{
+			name:    "run past memory",
+			patch:   IPS_MAGIC + "\x00\x00\x00\x00\x00\xFF\xFFx" + IPS_EOF,
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := ApplyPatch(source, []byte(test.patch))
+			if test.isError != (err != nil) {
+				t.Fatalf("error %v, expected one: %t", err, test.isError)
+			}
+			if !bytes.Equal(output, test.expected) {
+				t.Errorf("patched to %q, expected %q", output, test.expected)
+			}
+		})
+	}
+}
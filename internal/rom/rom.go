@@ -0,0 +1,116 @@
+// Package rom reads ROM images from disk, unpacking archives, Octo
+// cartridges and hex listings, and applies patches to them before they are
+// handed to core.Chip8.LoadRomBytes.
+package rom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MAX_PATCHED_SIZE is the largest ROM a patch may produce, the 4KB of
+// CHIP-8 memory. Anything larger could not be loaded anyway.
+const MAX_PATCHED_SIZE = 4096
+
+// PatchExtensions are the patch formats looked for next to a ROM.
+var PatchExtensions = []string{".bps", ".ips"}
+
+// ApplyPatch applies an IPS or BPS patch, detected by its header, to a ROM
+// image and returns the patched copy.
+func ApplyPatch(data, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte(BPS_MAGIC)):
+		return applyBPS(data, patch)
+	case bytes.HasPrefix(patch, []byte(IPS_MAGIC)):
+		return applyIPS(data, patch)
+	}
+	return nil, fmt.Errorf("unknown patch format: expected an IPS or BPS file")
+}
+
+// FindPatch returns the patch sitting next to a ROM with the same name,
+// e.g. "game.ips" for "game.ch8".
+func FindPatch(romPath string) (string, bool) {
+	base := strings.TrimSuffix(romPath, filepath.Ext(romPath))
+	for _, ext := range PatchExtensions {
+		path := base + ext
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Load reads a ROM file, unpacks it with Decode and applies patchPath to
+// the program. Without a patch path, a patch found by FindPatch is applied.
+// It returns the path of the applied patch, if any.
+func Load(romPath, patchPath string, pick Picker) (*Image, string, error) {
+	data, err := os.ReadFile(romPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read ROM file: %v", err)
+	}
+
+	image, err := Decode(filepath.Base(romPath), data, pick)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %v", romPath, err)
+	}
+
+	if patchPath == "" {
+		var ok bool
+		if patchPath, ok = FindPatch(romPath); !ok {
+			return image, "", nil
+		}
+	}
+
+	patch, err := os.ReadFile(patchPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read patch: %v", err)
+	}
+
+	image.Data, err = ApplyPatch(image.Data, patch)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to apply %s: %v", patchPath, err)
+	}
+
+	return image, patchPath, nil
+}
+
+// NamePicker returns a Picker choosing the ROM called name, to load the
+// same one from an archive again.
+func NamePicker(name string) Picker {
+	return func(names []string) (int, error) {
+		for i, candidate := range names {
+			if candidate == name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%s is no longer in the archive", name)
+	}
+}
+
+// PromptPicker returns a Picker listing the ROMs on out and reading the
+// number of the chosen one from in.
+func PromptPicker(in io.Reader, out io.Writer) Picker {
+	return func(names []string) (int, error) {
+		for i, name := range names {
+			fmt.Fprintf(out, "%2d) %s\n", i+1, name)
+		}
+		fmt.Fprintf(out, "ROM to load [1-%d]: ", len(names))
+
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && line == "" {
+			return 0, fmt.Errorf("no ROM chosen: %v", err)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			return 0, fmt.Errorf("invalid choice %q", strings.TrimSpace(line))
+		}
+		return n - 1, nil
+	}
+}
@@ -0,0 +1,72 @@
+// Package romdb looks up ROMs by SHA-1 in a database laid out like the
+// community CHIP-8 database: a programs.json array of programs and a
+// sha1-hashes.json object mapping ROM hashes to indices in that array.
+package romdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	PROGRAMS_FILE = "programs.json"
+	HASHES_FILE   = "sha1-hashes.json"
+)
+
+// Program is a database entry. A program may have several ROMs, e.g.
+// revisions or ports to other platforms.
+type Program struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Release     string   `json:"release"`
+	Authors     []string `json:"authors"`
+}
+
+type Database struct {
+	programs []Program
+	hashes   map[string]int
+}
+
+// Load reads the database in dir. A directory without a database gives an
+// empty one, so that lookups fall back to file names.
+func Load(dir string) (*Database, error) {
+	db := &Database{hashes: map[string]int{}}
+
+	programs, err := os.ReadFile(filepath.Join(dir, PROGRAMS_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return db, fmt.Errorf("failed to read ROM database: %v", err)
+	}
+
+	hashes, err := os.ReadFile(filepath.Join(dir, HASHES_FILE))
+	if err != nil {
+		return db, fmt.Errorf("failed to read ROM database: %v", err)
+	}
+
+	if err := json.Unmarshal(programs, &db.programs); err != nil {
+		return db, fmt.Errorf("failed to parse %s: %v", PROGRAMS_FILE, err)
+	}
+	if err := json.Unmarshal(hashes, &db.hashes); err != nil {
+		return db, fmt.Errorf("failed to parse %s: %v", HASHES_FILE, err)
+	}
+
+	return db, nil
+}
+
+// Lookup returns the program a ROM, given by its hex SHA-1, belongs to.
+func (db *Database) Lookup(hash string) (Program, bool) {
+	index, ok := db.hashes[hash]
+	if !ok || index < 0 || index >= len(db.programs) {
+		return Program{}, false
+	}
+	return db.programs[index], true
+}
+
+func (db *Database) Len() int {
+	return len(db.hashes)
+}
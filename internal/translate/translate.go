@@ -0,0 +1,225 @@
+// Package translate turns a CHIP-8 ROM into the Go source of a standalone
+// program. The code reachable from the entry of the machine is found by following
+// jumps, calls and skips, and every instruction becomes a case of a switch
+// on the program counter. Indirect jumps (BNNN) may land anywhere, so
+// their targets, like code the program overwrites, are left to the
+// interpreter at run time.
+package translate
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+// Analysis is the result of following the control flow of a ROM.
+type Analysis struct {
+	// Code holds the addresses of the reachable instructions, in order.
+	Code []uint16
+	// Indirect holds the addresses of the BNNN jumps found.
+	Indirect []uint16
+}
+
+// Analyse finds the instructions reachable from the entry of machine in
+// program, loaded where machine loads it. Code outside the ROM, such as
+// the fontset, is not followed.
+func Analyse(program []byte, machine core.Machine) Analysis {
+	start := machine.LoadAddress()
+	end := int(start) + len(program)
+	isVisited := make(map[uint16]bool)
+	pending := []uint16{machine.Entry()}
+
+	var analysis Analysis
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if isVisited[addr] || addr < start || int(addr)+1 >= end {
+			continue
+		}
+		isVisited[addr] = true
+		analysis.Code = append(analysis.Code, addr)
+
+		opcode := opcodeAt(program, start, addr)
+		next := (addr + 2) & 0xFFF
+		nnn := opcode & 0x0FFF
+
+		switch opcode >> 12 {
+		case 0x0:
+			// Like the tables, any 0NNN ending in E returns.
+			if opcode != 0x0000 && opcode&0x000F == 0xE {
+				continue
+			}
+			pending = append(pending, next)
+		case 0x1:
+			pending = append(pending, nnn)
+		case 0x2:
+			pending = append(pending, nnn, next)
+		case 0x3, 0x4, 0x5, 0x9, 0xE:
+			pending = append(pending, next, (addr+4)&0xFFF)
+		case 0xB:
+			// BXYN sets colours on CHIP-8X.
+			if machine == core.MachineChip8X {
+				pending = append(pending, next)
+				continue
+			}
+			analysis.Indirect = append(analysis.Indirect, addr)
+		default:
+			pending = append(pending, next)
+		}
+	}
+
+	sort.Slice(analysis.Code, func(i, j int) bool { return analysis.Code[i] < analysis.Code[j] })
+	sort.Slice(analysis.Indirect, func(i, j int) bool { return analysis.Indirect[i] < analysis.Indirect[j] })
+	return analysis
+}
+
+func opcodeAt(program []byte, start, addr uint16) uint16 {
+	offset := int(addr - start)
+	return uint16(program[offset])<<8 | uint16(program[offset+1])
+}
+
+// machineNames are the identifiers of the machines in the generated code.
+var machineNames = map[core.Machine]string{
+	core.MachineChip8:  "core.MachineChip8",
+	core.MachineChip48: "core.MachineChip48",
+	core.MachineChip8X: "core.MachineChip8X",
+	core.MachineHires:  "core.MachineHires",
+}
+
+// Translate returns the source of a main package running program, named
+// name, in a window on machine.
+func Translate(name string, program []byte, machine core.Machine) ([]byte, Analysis, error) {
+	machineName, ok := machineNames[machine]
+	if !ok {
+		return nil, Analysis{}, fmt.Errorf("cannot translate programs for %s", machine)
+	}
+	analysis := Analyse(program, machine)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by g8emu recompile from %s. DO NOT EDIT.\n\n", name)
+	b.WriteString(`package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/ebitenui"
+)
+
+func main() {
+	scale := flag.Int("scale", 10, "integer scale factor")
+	frequency := flag.Int("frequency", 540, "instructions executed per second")
+	flag.Parse()
+
+`)
+	fmt.Fprintf(&b, "if err := ebitenui.RunProgram(%q, rom, program{}, %s, *scale, *frequency); err != nil {\nlog.Fatal(err)\n}\n}\n\n", name, machineName)
+
+	b.WriteString("var rom = []byte{")
+	for i, value := range program {
+		if i%16 == 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "0x%02X, ", value)
+	}
+	b.WriteString("\n}\n\n")
+
+	b.WriteString(`// program runs the translated instructions until it reaches one it does
+// not know, or one overwritten since.
+type program struct{}
+
+func (program) Run(c *core.Chip8, budget int) int {
+	n := c.Native()
+
+	ran := 0
+	for ; ran < budget; ran++ {
+		switch *n.PC & 0xFFF {
+`)
+	for _, addr := range analysis.Code {
+		writeCase(&b, addr, opcodeAt(program, machine.LoadAddress(), addr), machine)
+	}
+	b.WriteString(`		default:
+			return ran
+		}
+	}
+	return ran
+}
+`)
+
+	source, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, analysis, fmt.Errorf("failed to format generated code: %v", err)
+	}
+
+	return source, analysis, nil
+}
+
+// writeCase translates the instruction at addr: it checks that the code
+// is unchanged, fetches it like the interpreter and runs it.
+func writeCase(b *strings.Builder, addr, opcode uint16, machine core.Machine) {
+	second := (addr + 1) & 0xFFF
+
+	fmt.Fprintf(b, "case 0x%03X: // %s\n", addr, core.Disassemble(opcode))
+	fmt.Fprintf(b, "if n.Memory[0x%03X] != 0x%02X || n.Memory[0x%03X] != 0x%02X {\nreturn ran\n}\n", addr, opcode>>8, second, opcode&0xFF)
+	fmt.Fprintf(b, "*n.PC, *n.Opcode = 0x%03X, 0x%04X\n", (addr+2)&0xFFF, opcode)
+	b.WriteString("*n.Cycles++\n")
+	b.WriteString(statement(opcode, machine))
+}
+
+// statement is the Go code of an instruction, with the same semantics as
+// its handler in core. Instructions without an inline translation call
+// the handler, which is the one of machine.
+func statement(opcode uint16, machine core.Machine) string {
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+	nn := opcode & 0x00FF
+	nnn := opcode & 0x0FFF
+
+	switch opcode >> 12 {
+	case 0x0:
+		if opcode == 0x0000 {
+			return ""
+		}
+	case 0x1:
+		return fmt.Sprintf("*n.PC = 0x%03X\n", nnn)
+	case 0x3:
+		return fmt.Sprintf("if n.V[0x%X] == 0x%02X {\n*n.PC += 2\n}\n", x, nn)
+	case 0x4:
+		return fmt.Sprintf("if n.V[0x%X] != 0x%02X {\n*n.PC += 2\n}\n", x, nn)
+	case 0x5:
+		// 5XY1 adds on CHIP-8X.
+		if machine != core.MachineChip8X {
+			return fmt.Sprintf("if n.V[0x%X] == n.V[0x%X] {\n*n.PC += 2\n}\n", x, y)
+		}
+	case 0x6:
+		return fmt.Sprintf("n.V[0x%X] = 0x%02X\n", x, nn)
+	case 0x7:
+		return fmt.Sprintf("n.V[0x%X] += 0x%02X\n", x, nn)
+	case 0x8:
+		operators := [...]string{"=", "|=", "&=", "^="}
+		if op := opcode & 0x000F; int(op) < len(operators) {
+			return fmt.Sprintf("n.V[0x%X] %s n.V[0x%X]\n", x, operators[op], y)
+		}
+	case 0x9:
+		return fmt.Sprintf("if n.V[0x%X] != n.V[0x%X] {\n*n.PC += 2\n}\n", x, y)
+	case 0xA:
+		return fmt.Sprintf("*n.Index = 0x%03X\n", nnn)
+	case 0xF:
+		switch nn {
+		case 0x07:
+			return fmt.Sprintf("n.V[0x%X] = c.DelayTimer\n", x)
+		case 0x15:
+			return fmt.Sprintf("c.DelayTimer = n.V[0x%X]\n", x)
+		case 0x18:
+			return fmt.Sprintf("c.SoundTimer = n.V[0x%X]\n", x)
+		case 0x1E:
+			return fmt.Sprintf("*n.Index += uint16(n.V[0x%X])\n", x)
+		}
+	}
+
+	return fmt.Sprintf("c.Exec(0x%04X)\n", opcode)
+}
@@ -0,0 +1,46 @@
+package translate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mochaeng/G8Emu/internal/core"
+)
+
+func TestAnalyse(t *testing.T) {
+	tests := []struct {
+		machine  core.Machine
+		program  []byte
+		code     []uint16
+		indirect []uint16
+	}{
+		{
+			machine:  core.MachineChip8,
+			program:  []byte{0x60, 0x05, 0xB2, 0x10},
+			code:     []uint16{0x200, 0x202},
+			indirect: []uint16{0x202},
+		},
+		{
+			machine: core.MachineChip8X,
+			program: []byte{0x60, 0x05, 0xB0, 0x11, 0x13, 0x00},
+			code:    []uint16{0x300, 0x302, 0x304},
+		},
+		{
+			machine: core.MachineHires,
+			program: append(append([]byte{0x12, 0x60}, make([]byte, 0xBE)...), 0x60, 0x01, 0x12, 0xC0),
+			code:    []uint16{0x2C0, 0x2C2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.machine.String(), func(t *testing.T) {
+			analysis := Analyse(test.program, test.machine)
+			if !reflect.DeepEqual(analysis.Code, test.code) {
+				t.Errorf("code at %03X, expected %03X", analysis.Code, test.code)
+			}
+			if !reflect.DeepEqual(analysis.Indirect, test.indirect) {
+				t.Errorf("indirect jumps at %03X, expected %03X", analysis.Indirect, test.indirect)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const DETECT_TIMEOUT = 200 * time.Millisecond
+
+// kittyQuery asks for a 1x1 test image to be checked without being stored.
+// Terminals without the Kitty graphics protocol silently ignore it.
+const kittyQuery = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+
+// DetectRenderer picks the best renderer supported by the terminal, which
+// must already be in raw mode. The Kitty graphics protocol is preferred
+// over Sixel, and half-blocks are used when neither is available.
+//
+// Support is queried by sending a Kitty graphics query followed by a
+// primary device attributes request, which every terminal answers.
+// Sixel capable terminals list attribute 4 in that answer.
+func DetectRenderer(in, out *os.File) Renderer {
+	term := os.Getenv("TERM")
+	if os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") || strings.Contains(term, "ghostty") {
+		return RendererKitty
+	}
+
+	io.WriteString(out, kittyQuery+"\x1b[c")
+	response, err := readResponse(int(in.Fd()), hasDeviceAttributes, DETECT_TIMEOUT)
+	if err != nil {
+		return RendererHalfBlock
+	}
+
+	if bytes.Contains(response, []byte("\x1b_Gi=31;OK")) {
+		return RendererKitty
+	}
+
+	if attributes, ok := deviceAttributes(response); ok {
+		for _, attribute := range strings.Split(attributes, ";") {
+			if attribute == "4" {
+				return RendererSixel
+			}
+		}
+	}
+
+	return RendererHalfBlock
+}
+
+func hasDeviceAttributes(response []byte) bool {
+	_, ok := deviceAttributes(response)
+	return ok
+}
+
+// deviceAttributes extracts the parameters of a "ESC [ ? ... c" answer.
+func deviceAttributes(response []byte) (string, bool) {
+	start := bytes.Index(response, []byte("\x1b[?"))
+	if start < 0 {
+		return "", false
+	}
+
+	rest := response[start+3:]
+	end := bytes.IndexByte(rest, 'c')
+	if end < 0 {
+		return "", false
+	}
+
+	return string(rest[:end]), true
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+const (
+	// SIXEL_SHADES is how many intensity levels between background and
+	// foreground get their own Sixel colour register.
+	SIXEL_SHADES     = 16
+	KITTY_IMAGE_ID   = 8
+	KITTY_CHUNK_SIZE = 4096
+)
+
+func renderSixel(buf *bytes.Buffer, frame emulator.Frame, palette emulator.Palette, scale int) {
+	width, height := frame.Width*scale, frame.Height*scale
+
+	shades := make([]uint8, len(frame.Pixels))
+	var isUsed [SIXEL_SHADES]bool
+	for i, intensity := range frame.Pixels {
+		level := uint8(min(max(intensity, 0), 1)*(SIXEL_SHADES-1) + 0.5)
+		shades[i] = level
+		isUsed[level] = true
+	}
+
+	// Pixel aspect 1:1, background pixels are painted explicitly.
+	fmt.Fprintf(buf, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for level, used := range isUsed {
+		if used {
+			c := palette.Shade(float32(level) / (SIXEL_SHADES - 1))
+			fmt.Fprintf(buf, "#%d;2;%d;%d;%d", level, percent(c.R), percent(c.G), percent(c.B))
+		}
+	}
+
+	row := make([]byte, width)
+	for band := 0; band < height; band += 6 {
+		isFirst := true
+		for level, used := range isUsed {
+			if !used {
+				continue
+			}
+
+			isEmpty := true
+			for x := range width {
+				bits := byte(0)
+				for dy := range 6 {
+					y := band + dy
+					if y < height && shades[(y/scale)*frame.Width+x/scale] == uint8(level) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+				isEmpty = isEmpty && bits == 0
+			}
+			if isEmpty {
+				continue
+			}
+
+			if !isFirst {
+				buf.WriteByte('$')
+			}
+			isFirst = false
+			fmt.Fprintf(buf, "#%d", level)
+			writeSixelRow(buf, row)
+		}
+		buf.WriteByte('-')
+	}
+
+	buf.WriteString("\x1b\\")
+}
+
+// writeSixelRow writes a row of sixels, run-length encoding repeats and
+// dropping the empty tail.
+func writeSixelRow(buf *bytes.Buffer, row []byte) {
+	row = bytes.TrimRight(row, "?")
+	for i := 0; i < len(row); {
+		count := 1
+		for i+count < len(row) && row[i+count] == row[i] {
+			count++
+		}
+
+		if count > 3 {
+			fmt.Fprintf(buf, "!%d%c", count, row[i])
+		} else {
+			buf.Write(row[i : i+count])
+		}
+		i += count
+	}
+}
+
+func percent(value uint8) int {
+	return (int(value)*100 + 127) / 255
+}
+
+// renderKitty transmits the frame as zlib compressed RGB data. Every frame
+// reuses the same image and placement ids, so the previous frame is
+// replaced instead of stacked.
+func renderKitty(buf *bytes.Buffer, frame emulator.Frame, palette emulator.Palette, scale int) {
+	width, height := frame.Width*scale, frame.Height*scale
+
+	pixels := make([]byte, width*height*3)
+	for y := range height {
+		for x := range width {
+			c := frame.Shade((y/scale)*frame.Width+x/scale, palette)
+			offset := (y*width + x) * 3
+			pixels[offset] = c.R
+			pixels[offset+1] = c.G
+			pixels[offset+2] = c.B
+		}
+	}
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(pixels)
+	writer.Close()
+
+	encoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	for start := 0; start < len(encoded); start += KITTY_CHUNK_SIZE {
+		end := min(start+KITTY_CHUNK_SIZE, len(encoded))
+		more := 0
+		if end < len(encoded) {
+			more = 1
+		}
+
+		if start == 0 {
+			fmt.Fprintf(buf, "\x1b_Ga=T,f=24,o=z,s=%d,v=%d,i=%d,p=1,q=2,C=1,m=%d;", width, height, KITTY_IMAGE_ID, more)
+		} else {
+			fmt.Fprintf(buf, "\x1b_Gm=%d;", more)
+		}
+		buf.WriteString(encoded[start:end])
+		buf.WriteString("\x1b\\")
+	}
+}
+
+// clearKitty deletes the image left by renderKitty.
+func clearKitty(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", KITTY_IMAGE_ID)
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+// Renderer turns a frame into terminal text.
+type Renderer int
+
+const (
+	// RendererHalfBlock draws two pixels per cell with "▀" and 24-bit
+	// foreground and background colours.
+	RendererHalfBlock Renderer = iota
+	// RendererBraille draws a 2x4 block of pixels per cell with Braille
+	// patterns, in the foreground colour only.
+	RendererBraille
+	// RendererSixel draws the frame as a Sixel image.
+	RendererSixel
+	// RendererKitty draws the frame with the Kitty graphics protocol.
+	RendererKitty
+	// RendererAuto picks the best renderer the terminal supports. It is
+	// resolved by DetectRenderer and never used to draw.
+	RendererAuto
+)
+
+var rendererNames = [...]string{
+	RendererHalfBlock: "halfblock",
+	RendererBraille:   "braille",
+	RendererSixel:     "sixel",
+	RendererKitty:     "kitty",
+	RendererAuto:      "auto",
+}
+
+func (r Renderer) String() string {
+	if int(r) < len(rendererNames) {
+		return rendererNames[r]
+	}
+	return fmt.Sprintf("Renderer(%d)", int(r))
+}
+
+func ParseRenderer(name string) (Renderer, error) {
+	for i, rendererName := range rendererNames {
+		if rendererName == name {
+			return Renderer(i), nil
+		}
+	}
+	return RendererHalfBlock, fmt.Errorf("unknown renderer %q (available: %s)", name, strings.Join(rendererNames[:], ", "))
+}
+
+// IsGraphics reports whether the renderer outputs images rather than text.
+func (r Renderer) IsGraphics() bool {
+	return r == RendererSixel || r == RendererKitty
+}
+
+// cellSize returns how many pixels a single character cell covers.
+func (r Renderer) cellSize() (width, height int) {
+	if r == RendererBraille {
+		return 2, 4
+	}
+	return 1, 2
+}
+
+// render appends the frame to buf. Graphics renderers draw every pixel as
+// a scale x scale square, text renderers ignore the scale.
+func (r Renderer) render(buf *bytes.Buffer, frame emulator.Frame, palette emulator.Palette, scale int) {
+	switch r {
+	case RendererBraille:
+		renderBraille(buf, frame, palette)
+	case RendererSixel:
+		renderSixel(buf, frame, palette, scale)
+	case RendererKitty:
+		renderKitty(buf, frame, palette, scale)
+	default:
+		renderHalfBlock(buf, frame, palette)
+	}
+}
+
+func renderHalfBlock(buf *bytes.Buffer, frame emulator.Frame, palette emulator.Palette) {
+	var lastTop, lastBottom color.RGBA
+	isFirst := true
+
+	for y := 0; y < frame.Height; y += 2 {
+		for x := range frame.Width {
+			top := frame.Shade(y*frame.Width+x, palette)
+			bottom := palette.Color(0)
+			if y+1 < frame.Height {
+				bottom = frame.Shade((y+1)*frame.Width+x, palette)
+			}
+
+			if isFirst || top != lastTop {
+				fmt.Fprintf(buf, "\x1b[38;2;%d;%d;%dm", top.R, top.G, top.B)
+			}
+			if isFirst || bottom != lastBottom {
+				fmt.Fprintf(buf, "\x1b[48;2;%d;%d;%dm", bottom.R, bottom.G, bottom.B)
+			}
+			lastTop, lastBottom, isFirst = top, bottom, false
+
+			buf.WriteString("▀")
+		}
+		buf.WriteString("\x1b[0m\r\n")
+		isFirst = true
+	}
+}
+
+// brailleDots maps a pixel offset inside a 2x4 cell to its Braille dot.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+func renderBraille(buf *bytes.Buffer, frame emulator.Frame, palette emulator.Palette) {
+	foreground := palette.Color(1)
+	background := palette.Color(0)
+	colours := fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm",
+		foreground.R, foreground.G, foreground.B, background.R, background.G, background.B)
+
+	for y := 0; y < frame.Height; y += 4 {
+		buf.WriteString(colours)
+		for x := 0; x < frame.Width; x += 2 {
+			pattern := rune(0x2800)
+			for dy := range 4 {
+				for dx := range 2 {
+					px, py := x+dx, y+dy
+					if px < frame.Width && py < frame.Height && frame.Pixels[py*frame.Width+px] >= 0.5 {
+						pattern |= brailleDots[dy][dx]
+					}
+				}
+			}
+			buf.WriteRune(pattern)
+		}
+		buf.WriteString("\x1b[0m\r\n")
+	}
+}
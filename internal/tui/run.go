@@ -0,0 +1,183 @@
+// Package tui runs the emulator inside a terminal, without a window or any
+// graphics library.
+package tui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/config"
+	"github.com/mochaeng/G8Emu/internal/core"
+	"github.com/mochaeng/G8Emu/internal/emulator"
+	"github.com/mochaeng/G8Emu/internal/rom"
+)
+
+// Run parses the tui subcommand arguments and runs a ROM until the user
+// quits with Escape, Ctrl-C or Ctrl-Q.
+func Run(name string, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("using default config: %v", err)
+	}
+
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	rendererName := flags.String("renderer", "halfblock", "terminal renderer: halfblock, braille, sixel, kitty or auto")
+	scale := flags.Int("scale", 4, "size in screen pixels of every CHIP-8 pixel for the sixel and kitty renderers")
+	releaseTime := flags.Duration("release", DEFAULT_RELEASE_TIME, "time without key repeats before a key counts as released")
+	paletteSpec := flags.String("palette", cfg.Palette, "colour palette name or 2-4 comma separated hex colours")
+	filterName := flags.String("filter", cfg.Filter, "display filter: none, decay, blend or wait")
+	patchPath := flags.String("patch", "", "IPS or BPS patch applied to the ROM (default: a .ips or .bps file next to it)")
+	cpuFrequency := flags.Int("frequency", 540, "instructions executed per second")
+	quirksSpec := flags.String("quirks", "", "comma separated behaviours of other interpreters: key-on-press, wrap")
+	timingName := flags.String("timing", "fixed", "instruction timing: fixed, at -frequency, or vip, as on the COSMAC VIP")
+	executionName := flags.String("execution", "cached", "how instructions run: cached, interpreter or recompiler")
+	machineName := flags.String("machine", "chip-8", "machine to emulate: chip-8, chip-48, chip-8x or chip-8-hires")
+	watch := flags.Bool("watch", false, "reload the ROM whenever the file changes")
+	watchKeepBreakpoints := flags.Bool("watch-keep-breakpoints", false, "keep breakpoints when the watched ROM reloads")
+	watchState := flags.String("watch-state", "", "save state restored after each reload of the watched ROM")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] <ROM>\n", name)
+		fmt.Fprintf(flags.Output(), "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	romFilename := flags.Arg(0)
+
+	renderer, err := ParseRenderer(*rendererName)
+	if err != nil {
+		return err
+	}
+
+	execution, err := core.ParseExecution(*executionName)
+	if err != nil {
+		return err
+	}
+
+	timing, err := emulator.ParseTiming(*timingName)
+	if err != nil {
+		return err
+	}
+
+	quirks, err := core.ParseQuirks(*quirksSpec)
+	if err != nil {
+		return err
+	}
+
+	machine, err := core.ParseMachine(*machineName)
+	if err != nil {
+		return err
+	}
+
+	if err := emulator.CheckCpuFrequency(*cpuFrequency); err != nil {
+		return err
+	}
+
+	image, _, err := rom.Load(romFilename, *patchPath, rom.PromptPicker(os.Stdin, os.Stdout))
+	if err != nil {
+		return err
+	}
+
+	isSet := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { isSet[f.Name] = true })
+	if len(image.Colors) > 0 && !isSet["palette"] {
+		*paletteSpec = strings.Join(image.Colors, ",")
+	}
+	if image.TickRate > 0 && !isSet["frequency"] {
+		*cpuFrequency = image.TickRate * 60
+	}
+
+	palette, err := emulator.ParsePalette(*paletteSpec)
+	if err != nil {
+		return fmt.Errorf("invalid palette: %v", err)
+	}
+
+	filter, err := emulator.ParseDisplayFilter(*filterName)
+	if err != nil {
+		return fmt.Errorf("invalid display filter: %v", err)
+	}
+
+	settings := emulator.DefaultDisplaySettings()
+	settings.Palette = palette
+	settings.Filter.Filter = filter
+
+	stdin := int(os.Stdin.Fd())
+	restore, err := makeRaw(stdin)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if renderer == RendererAuto {
+		renderer = DetectRenderer(os.Stdin, os.Stdout)
+	}
+	terminal := NewTerminal(os.Stdout, renderer, *scale, *releaseTime)
+
+	chip8 := core.NewChip8WithOptions(core.Options{Execution: execution, Quirks: quirks, Machine: machine})
+	engine := emulator.NewEngine(chip8, terminal, terminal, terminal, *cpuFrequency)
+	engine.SetDisplaySettings(settings)
+	engine.SetTiming(timing)
+	if err := engine.LoadRom(image.Name, image.Data); err != nil {
+		return fmt.Errorf("failed to load ROM: %v", err)
+	}
+
+	if screenshotDir, err := cfg.ScreenshotDirectory(); err == nil {
+		engine.SetScreenshotDir(screenshotDir)
+	}
+	if stateDir, err := config.StateDirectory(); err == nil {
+		engine.SetStateDir(stateDir)
+	}
+
+	if *watch {
+		options := emulator.WatchOptions{
+			KeepBreakpoints: *watchKeepBreakpoints,
+			StatePath:       *watchState,
+			PatchPath:       *patchPath,
+		}
+		if err := engine.Watch(romFilename, options); err != nil {
+			return err
+		}
+	}
+
+	width, height := chip8.VideoSize()
+	if columns, rows, err := terminalSize(stdin); err == nil && !renderer.IsGraphics() {
+		needColumns, needRows := terminal.Size(width, height)
+		if columns < needColumns || rows < needRows {
+			terminal.Notify(fmt.Sprintf("terminal is %dx%d, %dx%d needed", columns, rows, needColumns, needRows))
+		}
+	}
+
+	// Alternate screen and hidden cursor, undone in reverse on exit.
+	io.WriteString(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(os.Stdout, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	defer terminal.Close()
+
+	log.SetOutput(terminal)
+	defer log.SetOutput(os.Stderr)
+
+	go terminal.ReadInput(os.Stdin)
+
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminal.Done():
+			return engine.StopRecording()
+		case <-ticker.C:
+			if err := engine.Update(); err != nil {
+				return err
+			}
+			engine.Draw()
+		}
+	}
+}
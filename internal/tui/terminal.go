@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mochaeng/G8Emu/internal/emulator"
+)
+
+const (
+	DEFAULT_RELEASE_TIME = 150 * time.Millisecond
+	STATUS_TIME          = 3 * time.Second
+)
+
+// Terminal implements the emulator Display, Input and Audio on top of an
+// ANSI terminal in raw mode.
+//
+// Terminals only report key presses, never releases, so a CHIP-8 key is
+// considered held until no repeat for it arrived within the release time.
+type Terminal struct {
+	out      io.Writer
+	renderer Renderer
+	scale    int
+	release  time.Duration
+	now      func() time.Time
+
+	buf       bytes.Buffer
+	lastFrame []byte
+	lastSize  [2]int
+	status    string
+	statusEnd time.Time
+	isBuzzing bool
+
+	mu       sync.Mutex
+	keymap   map[byte]int
+	hotkeys  map[string]emulator.Hotkey
+	keyUntil [16]time.Time
+	pressed  map[emulator.Hotkey]bool
+	quit     chan struct{}
+	hasQuit  bool
+}
+
+// NewTerminal creates a terminal drawing with renderer. The scale only
+// applies to graphics renderers.
+func NewTerminal(out io.Writer, renderer Renderer, scale int, release time.Duration) *Terminal {
+	if release <= 0 {
+		release = DEFAULT_RELEASE_TIME
+	}
+
+	t := &Terminal{
+		out:      out,
+		renderer: renderer,
+		scale:    max(1, scale),
+		release:  release,
+		now:      time.Now,
+		pressed:  make(map[emulator.Hotkey]bool),
+		quit:     make(chan struct{}),
+	}
+
+	t.keymap = map[byte]int{
+		'x': 0x0, '1': 0x1, '2': 0x2, '3': 0x3,
+		'q': 0x4, 'w': 0x5, 'e': 0x6, 'a': 0x7,
+		's': 0x8, 'd': 0x9, 'z': 0xA, 'c': 0xB,
+		'4': 0xC, 'r': 0xD, 'f': 0xE, 'v': 0xF,
+	}
+
+	// Function keys are matched on the sequences sent by xterm compatible
+	// terminals, with the VT220 variants of F2-F4 as well.
+	t.hotkeys = map[string]emulator.Hotkey{
+		"p":        emulator.HotkeyPause,
+		"\x12":     emulator.HotkeyReset,
+		"\x1bOQ":   emulator.HotkeyPalette,
+		"\x1b[12~": emulator.HotkeyPalette,
+		"\x1bOS":   emulator.HotkeyFilter,
+		"\x1b[14~": emulator.HotkeyFilter,
+		"\x1b[20~": emulator.HotkeyRecord,
+		"\x1b[24~": emulator.HotkeyScreenshot,
+		// Shift+F12 and Shift+F11.
+		"\x1b[24;2~": emulator.HotkeySaveState,
+		"\x1b[23;2~": emulator.HotkeyLoadState,
+	}
+
+	return t
+}
+
+// Done is closed once the user asked to quit.
+func (t *Terminal) Done() <-chan struct{} {
+	return t.quit
+}
+
+// ReadInput reads key presses from r until it fails or the user quits.
+func (t *Terminal) ReadInput(r io.Reader) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Quit()
+			return
+		}
+		t.handleInput(buf[:n])
+	}
+}
+
+func (t *Terminal) Quit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeQuit()
+}
+
+func (t *Terminal) closeQuit() {
+	if !t.hasQuit {
+		t.hasQuit = true
+		close(t.quit)
+	}
+}
+
+// handleInput processes one read from the terminal. A lone escape byte is
+// the Escape key, while escape sequences arrive together in a single read.
+func (t *Terminal) handleInput(input []byte) {
+	if len(input) == 1 && input[0] == 0x1B {
+		t.Quit()
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for len(input) > 0 {
+		if input[0] == 0x1B {
+			length := escapeLength(input)
+			if hotkey, ok := t.hotkeys[string(input[:length])]; ok {
+				t.pressed[hotkey] = true
+			}
+			input = input[length:]
+			continue
+		}
+
+		b := input[0]
+		input = input[1:]
+
+		switch b {
+		case 0x03, 0x11: // Ctrl-C, Ctrl-Q
+			t.closeQuit()
+			continue
+		}
+
+		if hotkey, ok := t.hotkeys[string(b)]; ok {
+			t.pressed[hotkey] = true
+			continue
+		}
+
+		if b >= 'A' && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		if chipKey, ok := t.keymap[b]; ok {
+			t.keyUntil[chipKey] = t.now().Add(t.release)
+		}
+	}
+}
+
+// escapeLength returns the length of the escape sequence at the start of
+// input: ESC O <final> or a CSI sequence ending in a byte from 0x40-0x7E.
+func escapeLength(input []byte) int {
+	if len(input) < 2 {
+		return len(input)
+	}
+
+	switch input[1] {
+	case 'O':
+		return min(3, len(input))
+	case '[':
+		for i := 2; i < len(input); i++ {
+			if input[i] >= 0x40 && input[i] <= 0x7E {
+				return i + 1
+			}
+		}
+		return len(input)
+	}
+
+	return 1
+}
+
+func (t *Terminal) Keypad(keys []bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for i, until := range t.keyUntil {
+		keys[i] = now.Before(until)
+	}
+}
+
+func (t *Terminal) Pressed(hotkey emulator.Hotkey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	isPressed := t.pressed[hotkey]
+	delete(t.pressed, hotkey)
+	return isPressed
+}
+
+// Notify shows the message on the status line below the display.
+func (t *Terminal) Notify(message string) {
+	t.status = message
+	t.statusEnd = t.now().Add(STATUS_TIME)
+}
+
+// Write lets the terminal be used as log output, which would otherwise be
+// drawn over by the display.
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.Notify(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// SetBuzzer rings the terminal bell whenever the buzzer starts.
+func (t *Terminal) SetBuzzer(isOn bool) {
+	if isOn && !t.isBuzzing {
+		io.WriteString(t.out, "\a")
+	}
+	t.isBuzzing = isOn
+}
+
+// Present redraws the display from the top-left corner of the screen. Frames
+// identical to the previous one are not written again. The status line is
+// below text output and above images, whose height in rows is unknown.
+func (t *Terminal) Present(frame emulator.Frame, settings emulator.DisplaySettings) {
+	t.buf.Reset()
+
+	if size := [2]int{frame.Width, frame.Height}; size != t.lastSize {
+		t.buf.WriteString("\x1b[2J")
+		t.lastSize = size
+		t.lastFrame = nil
+	}
+
+	t.buf.WriteString("\x1b[H")
+	if t.renderer.IsGraphics() {
+		t.writeStatus()
+		t.buf.WriteString("\r\n")
+		t.renderer.render(&t.buf, frame, settings.Palette, t.scale)
+	} else {
+		t.renderer.render(&t.buf, frame, settings.Palette, t.scale)
+		t.writeStatus()
+	}
+
+	if bytes.Equal(t.buf.Bytes(), t.lastFrame) {
+		return
+	}
+	t.lastFrame = append(t.lastFrame[:0], t.buf.Bytes()...)
+	t.out.Write(t.buf.Bytes())
+}
+
+func (t *Terminal) writeStatus() {
+	if t.now().Before(t.statusEnd) {
+		t.buf.WriteString(t.status)
+	}
+	t.buf.WriteString("\x1b[K")
+}
+
+// Close removes images that would outlive the alternate screen.
+func (t *Terminal) Close() {
+	if t.renderer == RendererKitty {
+		t.buf.Reset()
+		clearKitty(&t.buf)
+		t.out.Write(t.buf.Bytes())
+	}
+}
+
+// Size returns how many columns and rows are needed to show a frame of the
+// given resolution with a text renderer, including the status line.
+func (t *Terminal) Size(width, height int) (columns, rows int) {
+	cellWidth, cellHeight := t.renderer.cellSize()
+	columns = (width + cellWidth - 1) / cellWidth
+	rows = (height+cellHeight-1)/cellHeight + 1
+	return columns, rows
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package tui
+
+import (
+	"errors"
+	"time"
+)
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("terminal mode is not supported on this platform")
+}
+
+func terminalSize(fd int) (columns, rows int, err error) {
+	return 0, 0, errors.New("terminal size is not supported on this platform")
+}
+
+func readResponse(fd int, isComplete func([]byte) bool, timeout time.Duration) ([]byte, error) {
+	return nil, errors.New("terminal queries are not supported on this platform")
+}
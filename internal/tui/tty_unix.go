@@ -0,0 +1,76 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// makeRaw switches the terminal to raw mode, so that key presses are read
+// one byte at a time without echo, and returns a function restoring it.
+func makeRaw(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, fmt.Errorf("not a terminal: %v", err)
+	}
+	original := *termios
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, fmt.Errorf("failed to enable raw mode: %v", err)
+	}
+
+	return func() {
+		unix.IoctlSetTermios(fd, ioctlWriteTermios, &original)
+	}, nil
+}
+
+func terminalSize(fd int) (columns, rows int, err error) {
+	size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(size.Col), int(size.Row), nil
+}
+
+// readResponse reads the answer to a terminal query until isComplete
+// accepts it, giving up when nothing arrives within timeout.
+func readResponse(fd int, isComplete func([]byte) bool, timeout time.Duration) ([]byte, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, fmt.Errorf("not a terminal: %v", err)
+	}
+	original := *termios
+	defer unix.IoctlSetTermios(fd, ioctlWriteTermios, &original)
+
+	termios.Cc[unix.VMIN] = 0
+	termios.Cc[unix.VTIME] = uint8(max(1, min(255, timeout/(100*time.Millisecond))))
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, err
+	}
+
+	var response []byte
+	buf := make([]byte, 256)
+	for !isComplete(response) {
+		n, err := unix.Read(fd, buf)
+		if err != nil {
+			return response, err
+		}
+		if n == 0 {
+			return response, fmt.Errorf("no answer from terminal")
+		}
+		response = append(response, buf[:n]...)
+	}
+
+	return response, nil
+}
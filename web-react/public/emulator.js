@@ -14,6 +14,10 @@ document.addEventListener("DOMContentLoaded", () => {
     });
 });
 
+function cheatStorageKey() {
+  return "g8emu-cheats-" + window.romHash();
+}
+
 window.addEventListener("message", (event) => {
   if (event.source !== parent) return;
 
@@ -22,7 +26,20 @@ window.addEventListener("message", (event) => {
   switch (event.data.type) {
     case "loadRom":
       if (window.loadRom) {
-        window.loadRom(event.data.data);
+        let result = window.loadRom(event.data.data, event.data.name);
+
+        // Archives with several ROMs return their names to choose from.
+        if (Array.isArray(result)) {
+          const list = result.map((name, i) => `${i + 1}) ${name}`).join("\n");
+          const choice = parseInt(prompt(`Choose a ROM:\n${list}`, "1"));
+          if (!(choice >= 1 && choice <= result.length)) break;
+          result = window.loadRom(event.data.data, event.data.name, result[choice - 1]);
+        }
+
+        const cheats = localStorage.getItem(cheatStorageKey());
+        if (cheats && window.setCheats) {
+          window.setCheats(cheats);
+        }
       } else {
         console.error("loadRom function not available");
       }
@@ -34,6 +51,12 @@ window.addEventListener("message", (event) => {
       }
       break;
 
+    case "powerCycle":
+      if (window.powerCycle) {
+        window.powerCycle();
+      }
+      break;
+
     case "togglePause":
       if (window.togglePause) {
         window.togglePause();
@@ -45,5 +68,71 @@ window.addEventListener("message", (event) => {
         window.setCpuFrequency(event.data.value);
       }
       break;
+
+    case "setPalette":
+      if (window.setPalette) {
+        window.setPalette(event.data.value);
+      }
+      break;
+
+    case "setPixelStyle":
+      if (window.setPixelStyle) {
+        window.setPixelStyle(event.data.value);
+      }
+      break;
+
+    case "screenshot":
+      if (window.takeScreenshot) {
+        const png = window.takeScreenshot(event.data.scale);
+        if (png instanceof Uint8Array) {
+          parent.postMessage({ type: "screenshot", data: png }, "*");
+        } else {
+          console.error("Screenshot failed:", png);
+        }
+      }
+      break;
+
+    case "setShader":
+      if (window.setShader) {
+        window.setShader(event.data.value);
+      }
+      break;
+
+    case "readMemory":
+      if (window.readMemory && window.getRegisters) {
+        const memory = window.readMemory(event.data.start, event.data.length);
+        parent.postMessage(
+          {
+            type: "memory",
+            start: event.data.start,
+            bytes: memory.bytes,
+            access: memory.access,
+            registers: window.getRegisters(),
+          },
+          "*",
+        );
+      }
+      break;
+
+    case "writeMemory":
+      if (window.writeMemory) {
+        window.writeMemory(event.data.address, event.data.value);
+      }
+      break;
+
+    case "cheat":
+      if (window.cheatCommand) {
+        const output = window.cheatCommand(event.data.command);
+        localStorage.setItem(cheatStorageKey(), window.getCheats());
+        parent.postMessage({ type: "cheat", output }, "*");
+      }
+      break;
+
+    case "setRegister":
+      if (window.setRegister) {
+        const error = window.setRegister(event.data.name, event.data.value);
+        if (error) console.error("setRegister failed:", error);
+      }
+      break;
   }
 });
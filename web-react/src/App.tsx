@@ -1,21 +1,43 @@
 import { useEffect, useRef, useState } from "react";
 import Emulator from "./components/Emulator";
 import { ControlPanel } from "./components/ControlPanel";
+import { CheatPanel } from "./components/CheatPanel";
 
 import "@fontsource/nerko-one";
 
+function downloadFile(data: Uint8Array, type: string, filename: string) {
+  const url = URL.createObjectURL(new Blob([data], { type }));
+  const link = document.createElement("a");
+  link.href = url;
+  link.download = filename;
+  link.click();
+  URL.revokeObjectURL(url);
+}
+
 export default function App() {
   const [emulatorReady, setEmulatorReady] = useState(false);
+  const [cheatOutput, setCheatOutput] = useState<string[]>([]);
   const emulatorRef = useRef<HTMLIFrameElement>(null);
 
   useEffect(() => {
     function handleMessage(event: MessageEvent) {
       if (
-        emulatorRef.current &&
-        event.source === emulatorRef.current.contentWindow &&
-        event.data.type === "ready"
+        !emulatorRef.current ||
+        event.source !== emulatorRef.current.contentWindow
       ) {
-        setEmulatorReady(true);
+        return;
+      }
+
+      switch (event.data.type) {
+        case "ready":
+          setEmulatorReady(true);
+          break;
+        case "screenshot":
+          downloadFile(event.data.data, "image/png", "g8emu-screenshot.png");
+          break;
+        case "cheat":
+          setCheatOutput((lines) => [...lines, event.data.output].slice(-50));
+          break;
       }
     }
 
@@ -32,7 +54,7 @@ export default function App() {
 
       const romData = new Uint8Array(e.target.result);
       emulatorRef.current!.contentWindow!.postMessage(
-        { type: "loadRom", data: romData },
+        { type: "loadRom", data: romData, name: file.name },
         "*",
       );
     };
@@ -45,6 +67,11 @@ export default function App() {
     emulatorRef.current.contentWindow!.postMessage({ type: "reset" }, "*");
   };
 
+  const handlePowerCycle = () => {
+    if (!emulatorRef.current) return;
+    emulatorRef.current.contentWindow!.postMessage({ type: "powerCycle" }, "*");
+  };
+
   const handlePause = () => {
     if (!emulatorRef.current) return;
     emulatorRef.current.contentWindow!.postMessage(
@@ -61,6 +88,39 @@ export default function App() {
     );
   };
 
+  const handleScreenshot = () => {
+    if (!emulatorRef.current) return;
+    emulatorRef.current.contentWindow!.postMessage(
+      { type: "screenshot", scale: 10 },
+      "*",
+    );
+  };
+
+  const handlePaletteChange = (value: string) => {
+    if (!emulatorRef.current) return;
+    emulatorRef.current.contentWindow!.postMessage(
+      { type: "setPalette", value },
+      "*",
+    );
+  };
+
+  const handlePixelStyleChange = (value: string) => {
+    if (!emulatorRef.current) return;
+    emulatorRef.current.contentWindow!.postMessage(
+      { type: "setPixelStyle", value },
+      "*",
+    );
+  };
+
+  const handleCheatCommand = (command: string) => {
+    if (!emulatorRef.current) return;
+    setCheatOutput((lines) => [...lines, "> " + command]);
+    emulatorRef.current.contentWindow!.postMessage(
+      { type: "cheat", command },
+      "*",
+    );
+  };
+
   return (
     <div className="min-h-screen bg-background text-primary p-4 sm:p-8">
       <header className="text-center mb-8 pb-6 border-b border-border/30">
@@ -74,13 +134,24 @@ export default function App() {
         <div className="bg-card rounded-xl flex-1 p-4 shadow-xl border border-border/10">
           <Emulator ref={emulatorRef} />
         </div>
-        <ControlPanel
-          onRomUpload={handleRomUpload}
-          onReset={handleReset}
-          onPause={handlePause}
-          onCpuFrequencyChange={handleCpuFrequencyChange}
-          disabled={!emulatorReady}
-        />
+        <div className="flex flex-col gap-8">
+          <ControlPanel
+            onRomUpload={handleRomUpload}
+            onReset={handleReset}
+            onPowerCycle={handlePowerCycle}
+            onPause={handlePause}
+            onScreenshot={handleScreenshot}
+            onCpuFrequencyChange={handleCpuFrequencyChange}
+            onPaletteChange={handlePaletteChange}
+            onPixelStyleChange={handlePixelStyleChange}
+            disabled={!emulatorReady}
+          />
+          <CheatPanel
+            output={cheatOutput}
+            onCommand={handleCheatCommand}
+            disabled={!emulatorReady}
+          />
+        </div>
       </main>
 
       <footer className="mt-12 text-center text-primary border-t border-border/30 pt-6 text-lg">
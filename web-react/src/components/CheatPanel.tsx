@@ -0,0 +1,56 @@
+import { useState, type FormEvent } from "react";
+import { Button } from "./ui/button";
+import { Card, CardContent, CardHeader, CardTitle } from "./ui/card";
+import { Input } from "./ui/input";
+
+export function CheatPanel({
+  output,
+  onCommand,
+  disabled,
+}: {
+  output: string[];
+  onCommand: (command: string) => void;
+  disabled: boolean;
+}) {
+  const [command, setCommand] = useState("");
+
+  const handleSubmit = (e: FormEvent) => {
+    e.preventDefault();
+    if (!command.trim()) return;
+    onCommand(command.trim());
+    setCommand("");
+  };
+
+  return (
+    <Card className="bg-background border-border/20">
+      <CardHeader>
+        <CardTitle className="text-primary text-lg font-semibold">
+          Cheats
+        </CardTitle>
+      </CardHeader>
+      <CardContent className="space-y-2">
+        <pre className="bg-primary p-4 rounded-md text-sm text-white h-40 overflow-y-auto border border-border/30 whitespace-pre-wrap">
+          {output.length > 0
+            ? output.join("\n")
+            : "Type help for commands, e.g. search eq 3 or add freeze 2F0=05 lives"}
+        </pre>
+        <form onSubmit={handleSubmit} className="flex gap-2">
+          <Input
+            value={command}
+            onChange={(e) => setCommand(e.target.value)}
+            placeholder="search changed"
+            disabled={disabled}
+            className="bg-background border-border/30 text-primary"
+          />
+          <Button
+            type="submit"
+            disabled={disabled}
+            className="bg-primary hover:bg-primary/80 text-white border-0 font-medium"
+          >
+            Run
+          </Button>
+        </form>
+      </CardContent>
+    </Card>
+  );
+}
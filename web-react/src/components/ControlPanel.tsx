@@ -12,14 +12,22 @@ import {
 export function ControlPanel({
   onRomUpload,
   onReset,
+  onPowerCycle,
   onPause,
+  onScreenshot,
   onCpuFrequencyChange,
+  onPaletteChange,
+  onPixelStyleChange,
   disabled,
 }: {
   onRomUpload: (file: File | null) => void;
   onReset: () => void;
+  onPowerCycle: () => void;
   onPause: () => void;
+  onScreenshot: () => void;
   onCpuFrequencyChange: (value: string) => void;
+  onPaletteChange: (value: string) => void;
+  onPixelStyleChange: (value: string) => void;
   disabled: boolean;
 }) {
   return (
@@ -35,7 +43,7 @@ export function ControlPanel({
           <input
             id="rom-upload"
             type="file"
-            accept=".ch8"
+            accept=".ch8,.c8,.sc8,.xo8,.8o,.zip,.gif,.hex,.txt"
             onChange={(e) => onRomUpload(e.target.files?.[0] || null)}
             disabled={disabled}
             className="flex h-10 w-full rounded-md border border-border/30 bg-background px-3 py-2 text-sm text-primary file:mr-4 file:py-1 file:px-4 file:rounded file:border-0 file:bg-primary file:text-white hover:file:bg-primary/80 disabled:opacity-50 focus:border-border focus:ring-1 focus:ring-ring focus:outline-none"
@@ -57,6 +65,38 @@ export function ControlPanel({
           </Select>
         </div>
 
+        <div className="grid grid-cols-2 gap-4">
+          <div className="space-y-2">
+            <Label className="text-primary font-medium text-lg">Palette</Label>
+            <Select onValueChange={onPaletteChange} disabled={disabled}>
+              <SelectTrigger className="bg-background border-border/30 text-primary focus:border-border focus:ring-1 focus:ring-ring">
+                <SelectValue placeholder="Classic" />
+              </SelectTrigger>
+              <SelectContent className="bg-background border-border/30 text-primary">
+                <SelectItem value="classic">Classic</SelectItem>
+                <SelectItem value="amber">Amber</SelectItem>
+                <SelectItem value="green">Green Phosphor</SelectItem>
+                <SelectItem value="gameboy">Game Boy</SelectItem>
+                <SelectItem value="octo">Octo</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          <div className="space-y-2">
+            <Label className="text-primary font-medium text-lg">Pixels</Label>
+            <Select onValueChange={onPixelStyleChange} disabled={disabled}>
+              <SelectTrigger className="bg-background border-border/30 text-primary focus:border-border focus:ring-1 focus:ring-ring">
+                <SelectValue placeholder="Square" />
+              </SelectTrigger>
+              <SelectContent className="bg-background border-border/30 text-primary">
+                <SelectItem value="square">Square</SelectItem>
+                <SelectItem value="grid">Grid</SelectItem>
+                <SelectItem value="led">LED</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+        </div>
+
         <div className="grid grid-cols-2 gap-4">
           <Button
             onClick={onReset}
@@ -72,6 +112,20 @@ export function ControlPanel({
           >
             Pause
           </Button>
+          <Button
+            onClick={onPowerCycle}
+            disabled={disabled}
+            className="bg-background hover:bg-background/80 text-primary border-0 font-medium text-lg"
+          >
+            Power Cycle
+          </Button>
+          <Button
+            onClick={onScreenshot}
+            disabled={disabled}
+            className="bg-background hover:bg-background/80 text-primary border-0 font-medium text-lg"
+          >
+            Screenshot
+          </Button>
         </div>
 
         <Card className="bg-background border-border/20">